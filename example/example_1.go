@@ -41,4 +41,9 @@ func main() {
 	}
 
 	fmt.Println(extractor.HasParamMarker())
+
+	// 按语句遍历结果
+	for _, stmt := range extractor.Statements() {
+		fmt.Printf("第 %d 个SQL [%d, %d): %s\n", stmt.Index, stmt.StartOffset, stmt.EndOffset, stmt.TemplatizedSQL)
+	}
 }
@@ -0,0 +1,571 @@
+package extract
+
+import (
+	"strings"
+
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/parser/format"
+	"github.com/pingcap/tidb/pkg/parser/test_driver"
+
+	"github.com/kydenul/sql-extractor/models"
+)
+
+// restoreFlags 用于 DDL 中不含字面值部分 (字段类型、约束、表选项等) 的还原,
+// 不使用反引号，与其他模板化 SQL 的风格保持一致
+const restoreFlags = format.RestoreKeyWordUppercase | format.RestoreStringSingleQuotes
+
+// restorer 可还原为 SQL 文本的节点, 如 ast.Node, *types.FieldType
+type restorer interface {
+	Restore(ctx *format.RestoreCtx) error
+}
+
+// restore 将节点原样还原到 SQL 字符串中
+func (v *ExtractVisitor) restore(node restorer) {
+	if err := node.Restore(format.NewRestoreCtx(restoreFlags, v.builder)); err != nil {
+		v.reportUnhandled(node, "restore: "+err.Error())
+	}
+}
+
+// appendRestored 将节点还原结果添加到 SQL 字符串, 还原结果为空时不添加前缀
+func (v *ExtractVisitor) appendRestored(prefix string, node restorer) {
+	var sb strings.Builder
+	if err := node.Restore(format.NewRestoreCtx(restoreFlags, &sb)); err != nil {
+		v.reportUnhandled(node, "restore: "+err.Error())
+	}
+
+	if sb.Len() > 0 {
+		v.builder.WriteString(prefix)
+		v.builder.WriteString(sb.String())
+	}
+}
+
+// handleCreateTableStmt 处理 CREATE TABLE 语句
+func (v *ExtractVisitor) handleCreateTableStmt(node *ast.CreateTableStmt) {
+	if v.opType == models.SQLOperationUnknown {
+		v.opType = models.SQLOperationCreate
+	}
+	v.access = models.AccessDDL
+
+	switch node.TemporaryKeyword {
+	case ast.TemporaryGlobal:
+		v.builder.WriteString("CREATE GLOBAL TEMPORARY TABLE ")
+	case ast.TemporaryLocal:
+		v.builder.WriteString("CREATE TEMPORARY TABLE ")
+	default:
+		v.builder.WriteString("CREATE TABLE ")
+	}
+
+	if node.IfNotExists {
+		v.builder.WriteString("IF NOT EXISTS ")
+	}
+
+	node.Table.Accept(v)
+
+	// CREATE TABLE ... LIKE ...
+	if node.ReferTable != nil {
+		v.builder.WriteString(" LIKE ")
+		v.access = models.AccessRead
+		node.ReferTable.Accept(v)
+		v.access = models.AccessDDL
+	}
+
+	// 列定义及约束
+	if len(node.Cols)+len(node.Constraints) > 0 {
+		v.builder.WriteString(" (")
+		for idx := range node.Cols {
+			if idx > 0 {
+				v.builder.WriteString(", ")
+			}
+
+			v.appendColumnDef(node.Cols[idx])
+		}
+
+		for idx := range node.Constraints {
+			if idx > 0 || len(node.Cols) > 0 {
+				v.builder.WriteString(", ")
+			}
+
+			v.appendConstraint(node.Constraints[idx])
+		}
+		v.builder.WriteString(")")
+	}
+
+	// 表选项
+	for idx := range node.Options {
+		v.builder.WriteString(" ")
+		v.appendTableOption(node.Options[idx])
+	}
+
+	// 分区
+	if node.Partition != nil {
+		v.builder.WriteString(" ")
+		v.restore(node.Partition)
+	}
+
+	// CREATE TABLE ... AS SELECT ...
+	if node.Select != nil {
+		switch node.OnDuplicate {
+		case ast.OnDuplicateKeyHandlingIgnore:
+			v.builder.WriteString(" IGNORE AS ")
+		case ast.OnDuplicateKeyHandlingReplace:
+			v.builder.WriteString(" REPLACE AS ")
+		default:
+			v.builder.WriteString(" AS ")
+		}
+
+		node.Select.Accept(v)
+	}
+}
+
+// handleAlterTableStmt 处理 ALTER TABLE 语句
+func (v *ExtractVisitor) handleAlterTableStmt(node *ast.AlterTableStmt) {
+	if v.opType == models.SQLOperationUnknown {
+		v.opType = models.SQLOperationAlter
+	}
+	v.access = models.AccessDDL
+
+	v.builder.WriteString("ALTER TABLE ")
+	node.Table.Accept(v)
+
+	for idx := range node.Specs {
+		if idx > 0 {
+			v.builder.WriteString(",")
+		}
+
+		v.builder.WriteString(" ")
+		v.handleAlterTableSpec(node.Specs[idx])
+	}
+}
+
+// handleAlterTableSpec 处理 ALTER TABLE 的单个变更项
+//
+// 涉及列定义、表选项、重命名及交换分区的变更项需要参数化字面值或记录表信息，
+// 其余变更项不含字面值，原样还原
+//
+//nolint:cyclop
+func (v *ExtractVisitor) handleAlterTableSpec(spec *ast.AlterTableSpec) {
+	switch spec.Tp {
+	case ast.AlterTableAddColumns:
+		v.builder.WriteString("ADD COLUMN ")
+		if spec.IfNotExists {
+			v.builder.WriteString("IF NOT EXISTS ")
+		}
+
+		if spec.Position != nil && len(spec.NewColumns) == 1 {
+			v.appendColumnDef(spec.NewColumns[0])
+			v.appendColumnPosition(spec.Position)
+			return
+		}
+
+		v.builder.WriteString("(")
+		for idx := range spec.NewColumns {
+			if idx > 0 {
+				v.builder.WriteString(", ")
+			}
+
+			v.appendColumnDef(spec.NewColumns[idx])
+		}
+
+		for idx := range spec.NewConstraints {
+			if idx > 0 || len(spec.NewColumns) > 0 {
+				v.builder.WriteString(", ")
+			}
+
+			v.appendConstraint(spec.NewConstraints[idx])
+		}
+		v.builder.WriteString(")")
+
+	case ast.AlterTableModifyColumn:
+		v.builder.WriteString("MODIFY COLUMN ")
+		if spec.IfExists {
+			v.builder.WriteString("IF EXISTS ")
+		}
+
+		v.appendColumnDef(spec.NewColumns[0])
+		v.appendColumnPosition(spec.Position)
+
+	case ast.AlterTableChangeColumn:
+		v.builder.WriteString("CHANGE COLUMN ")
+		if spec.IfExists {
+			v.builder.WriteString("IF EXISTS ")
+		}
+
+		v.builder.WriteString(spec.OldColumnName.Name.O)
+		v.builder.WriteString(" ")
+		v.appendColumnDef(spec.NewColumns[0])
+		v.appendColumnPosition(spec.Position)
+
+	case ast.AlterTableAlterColumn:
+		v.builder.WriteString("ALTER COLUMN ")
+		v.builder.WriteString(spec.NewColumns[0].Name.Name.O)
+
+		if len(spec.NewColumns[0].Options) == 1 {
+			v.builder.WriteString(" SET DEFAULT ")
+			expr := spec.NewColumns[0].Options[0].Expr
+			if _, ok := expr.(*test_driver.ValueExpr); ok {
+				expr.Accept(v)
+			} else {
+				v.builder.WriteString("(")
+				expr.Accept(v)
+				v.builder.WriteString(")")
+			}
+		} else {
+			v.builder.WriteString(" DROP DEFAULT")
+		}
+
+	case ast.AlterTableOption:
+		for idx := range spec.Options {
+			if idx > 0 {
+				v.builder.WriteString(" ")
+			}
+
+			v.appendTableOption(spec.Options[idx])
+		}
+
+	case ast.AlterTableAddConstraint:
+		v.builder.WriteString("ADD ")
+		v.appendConstraint(spec.Constraint)
+
+	case ast.AlterTableRenameTable:
+		v.builder.WriteString("RENAME AS ")
+		spec.NewTable.Accept(v)
+
+	case ast.AlterTableExchangePartition:
+		v.builder.WriteString("EXCHANGE PARTITION ")
+		v.builder.WriteString(spec.PartitionNames[0].O)
+		v.builder.WriteString(" WITH TABLE ")
+		spec.NewTable.Accept(v)
+		if !spec.WithValidation {
+			v.builder.WriteString(" WITHOUT VALIDATION")
+		}
+
+	default:
+		v.restore(spec)
+	}
+}
+
+// handleDropTableStmt 处理 DROP TABLE / DROP VIEW 语句
+func (v *ExtractVisitor) handleDropTableStmt(node *ast.DropTableStmt) {
+	if v.opType == models.SQLOperationUnknown {
+		v.opType = models.SQLOperationDrop
+	}
+	v.access = models.AccessDDL
+
+	v.builder.WriteString("DROP ")
+	switch {
+	case node.IsView:
+		v.builder.WriteString("VIEW ")
+	case node.TemporaryKeyword == ast.TemporaryGlobal:
+		v.builder.WriteString("GLOBAL TEMPORARY TABLE ")
+	case node.TemporaryKeyword == ast.TemporaryLocal:
+		v.builder.WriteString("TEMPORARY TABLE ")
+	default:
+		v.builder.WriteString("TABLE ")
+	}
+
+	if node.IfExists {
+		v.builder.WriteString("IF EXISTS ")
+	}
+
+	for idx := range node.Tables {
+		if idx > 0 {
+			v.builder.WriteString(", ")
+		}
+
+		node.Tables[idx].Accept(v)
+	}
+}
+
+// handleTruncateTableStmt 处理 TRUNCATE TABLE 语句
+func (v *ExtractVisitor) handleTruncateTableStmt(node *ast.TruncateTableStmt) {
+	if v.opType == models.SQLOperationUnknown {
+		v.opType = models.SQLOperationTruncate
+	}
+	v.access = models.AccessDDL
+
+	v.builder.WriteString("TRUNCATE TABLE ")
+	node.Table.Accept(v)
+}
+
+// handleRenameTableStmt 处理 RENAME TABLE 语句, 新旧表名均计入表信息
+func (v *ExtractVisitor) handleRenameTableStmt(node *ast.RenameTableStmt) {
+	if v.opType == models.SQLOperationUnknown {
+		v.opType = models.SQLOperationRename
+	}
+	v.access = models.AccessDDL
+
+	v.builder.WriteString("RENAME TABLE ")
+	for idx := range node.TableToTables {
+		if idx > 0 {
+			v.builder.WriteString(", ")
+		}
+
+		node.TableToTables[idx].OldTable.Accept(v)
+		v.builder.WriteString(" TO ")
+		node.TableToTables[idx].NewTable.Accept(v)
+	}
+}
+
+// handleCreateIndexStmt 处理 CREATE INDEX 语句
+func (v *ExtractVisitor) handleCreateIndexStmt(node *ast.CreateIndexStmt) {
+	if v.opType == models.SQLOperationUnknown {
+		v.opType = models.SQLOperationCreateIndex
+	}
+	v.access = models.AccessDDL
+
+	v.builder.WriteString("CREATE ")
+	switch node.KeyType {
+	case ast.IndexKeyTypeUnique:
+		v.builder.WriteString("UNIQUE ")
+	case ast.IndexKeyTypeSpatial:
+		v.builder.WriteString("SPATIAL ")
+	case ast.IndexKeyTypeFulltext:
+		v.builder.WriteString("FULLTEXT ")
+	case ast.IndexKeyTypeVector:
+		v.builder.WriteString("VECTOR ")
+	case ast.IndexKeyTypeColumnar:
+		v.builder.WriteString("COLUMNAR ")
+	default:
+	}
+
+	v.builder.WriteString("INDEX ")
+	if node.IfNotExists {
+		v.builder.WriteString("IF NOT EXISTS ")
+	}
+	v.builder.WriteString(node.IndexName)
+	v.builder.WriteString(" ON ")
+	node.Table.Accept(v)
+
+	v.appendIndexParts(node.IndexPartSpecifications)
+
+	if node.IndexOption != nil {
+		v.appendIndexOption(node.IndexOption)
+	}
+
+	if node.LockAlg != nil {
+		v.appendRestored(" ", node.LockAlg)
+	}
+}
+
+// handleDropIndexStmt 处理 DROP INDEX 语句
+func (v *ExtractVisitor) handleDropIndexStmt(node *ast.DropIndexStmt) {
+	if v.opType == models.SQLOperationUnknown {
+		v.opType = models.SQLOperationDropIndex
+	}
+	v.access = models.AccessDDL
+
+	v.builder.WriteString("DROP INDEX ")
+	if node.IfExists {
+		v.builder.WriteString("IF EXISTS ")
+	}
+	v.builder.WriteString(node.IndexName)
+	v.builder.WriteString(" ON ")
+	node.Table.Accept(v)
+
+	if node.LockAlg != nil {
+		v.appendRestored(" ", node.LockAlg)
+	}
+}
+
+// appendColumnDef 添加列定义到 SQL 字符串, DEFAULT / COMMENT / ON UPDATE 中的字面值会被参数化
+func (v *ExtractVisitor) appendColumnDef(col *ast.ColumnDef) {
+	v.builder.WriteString(col.Name.Name.O)
+
+	if col.Tp != nil {
+		v.builder.WriteString(" ")
+		v.restore(col.Tp)
+	}
+
+	for idx := range col.Options {
+		if col.Options[idx].Tp == ast.ColumnOptionNoOption {
+			continue
+		}
+
+		v.builder.WriteString(" ")
+		v.appendColumnOption(col.Options[idx])
+	}
+}
+
+// appendColumnOption 添加列选项到 SQL 字符串
+func (v *ExtractVisitor) appendColumnOption(opt *ast.ColumnOption) {
+	switch opt.Tp {
+	case ast.ColumnOptionDefaultValue:
+		v.builder.WriteString("DEFAULT ")
+
+		// 除 CURRENT_TIMESTAMP 外的函数默认值需要括号, e.g. DEFAULT (UUID())
+		fn, isFunc := opt.Expr.(*ast.FuncCallExpr)
+		withParentheses := isFunc && fn.FnName.L != ast.CurrentTimestamp
+		if withParentheses {
+			v.builder.WriteString("(")
+		}
+		opt.Expr.Accept(v)
+		if withParentheses {
+			v.builder.WriteString(")")
+		}
+
+	case ast.ColumnOptionComment:
+		v.builder.WriteString("COMMENT ")
+		opt.Expr.Accept(v)
+
+	case ast.ColumnOptionOnUpdate:
+		v.builder.WriteString("ON UPDATE ")
+		opt.Expr.Accept(v)
+
+	case ast.ColumnOptionReference:
+		v.appendReference(opt.Refer)
+
+	case ast.ColumnOptionCheck:
+		if opt.ConstraintName != "" {
+			v.builder.WriteString("CONSTRAINT ")
+			v.builder.WriteString(opt.ConstraintName)
+			v.builder.WriteString(" ")
+		}
+		v.appendCheck(opt.Expr, opt.Enforced)
+
+	default:
+		v.restore(opt)
+	}
+}
+
+// appendColumnPosition 添加列位置 (FIRST / AFTER col) 到 SQL 字符串
+func (v *ExtractVisitor) appendColumnPosition(pos *ast.ColumnPosition) {
+	if pos == nil || pos.Tp == ast.ColumnPositionNone {
+		return
+	}
+
+	v.builder.WriteString(" ")
+	v.restore(pos)
+}
+
+// appendConstraint 添加约束 (PRIMARY KEY, UNIQUE, INDEX, FOREIGN KEY, CHECK ...) 到 SQL 字符串,
+// 外键引用的表记录为读访问, CHECK 表达式及索引 COMMENT 中的字面值会被参数化
+func (v *ExtractVisitor) appendConstraint(c *ast.Constraint) {
+	if c.Name != "" && (c.Tp == ast.ConstraintForeignKey || c.Tp == ast.ConstraintCheck) {
+		v.builder.WriteString("CONSTRAINT ")
+		v.builder.WriteString(c.Name)
+		v.builder.WriteString(" ")
+	}
+
+	switch c.Tp {
+	case ast.ConstraintCheck:
+		v.appendCheck(c.Expr, c.Enforced)
+		return
+	case ast.ConstraintForeignKey:
+		v.builder.WriteString("FOREIGN KEY")
+	case ast.ConstraintPrimaryKey:
+		v.builder.WriteString("PRIMARY KEY")
+	case ast.ConstraintKey, ast.ConstraintIndex:
+		v.builder.WriteString("INDEX")
+	case ast.ConstraintUniq, ast.ConstraintUniqKey, ast.ConstraintUniqIndex:
+		v.builder.WriteString("UNIQUE INDEX")
+	case ast.ConstraintFulltext:
+		v.builder.WriteString("FULLTEXT INDEX")
+	case ast.ConstraintVector:
+		v.builder.WriteString("VECTOR INDEX")
+	case ast.ConstraintColumnar:
+		v.builder.WriteString("COLUMNAR INDEX")
+	default:
+		v.restore(c)
+		return
+	}
+
+	if c.IfNotExists {
+		v.builder.WriteString(" IF NOT EXISTS")
+	}
+	if c.Tp != ast.ConstraintForeignKey && c.Name != "" {
+		v.builder.WriteString(" ")
+		v.builder.WriteString(c.Name)
+	}
+
+	v.appendIndexParts(c.Keys)
+
+	if c.Refer != nil {
+		v.builder.WriteString(" ")
+		v.appendReference(c.Refer)
+	}
+
+	if c.Option != nil && !c.Option.IsEmpty() {
+		v.appendIndexOption(c.Option)
+	}
+}
+
+// appendIndexParts 添加索引列列表, e.g. (a, b(10) DESC)
+func (v *ExtractVisitor) appendIndexParts(parts []*ast.IndexPartSpecification) {
+	v.builder.WriteString(" (")
+	for idx := range parts {
+		if idx > 0 {
+			v.builder.WriteString(", ")
+		}
+
+		v.restore(parts[idx])
+	}
+	v.builder.WriteString(")")
+}
+
+// appendReference 添加外键引用 (REFERENCES tbl (cols) ...) 到 SQL 字符串, 被引用的表记录为读访问
+func (v *ExtractVisitor) appendReference(ref *ast.ReferenceDef) {
+	v.builder.WriteString("REFERENCES ")
+	access := v.access
+	v.access = models.AccessRead
+	ref.Table.Accept(v)
+	v.access = access
+
+	if len(ref.IndexPartSpecifications) > 0 {
+		v.appendIndexParts(ref.IndexPartSpecifications)
+	}
+
+	switch ref.Match {
+	case ast.MatchFull:
+		v.builder.WriteString(" MATCH FULL")
+	case ast.MatchPartial:
+		v.builder.WriteString(" MATCH PARTIAL")
+	case ast.MatchSimple:
+		v.builder.WriteString(" MATCH SIMPLE")
+	default:
+	}
+
+	if ref.OnDelete != nil && ref.OnDelete.ReferOpt != ast.ReferOptionNoOption {
+		v.appendRestored(" ", ref.OnDelete)
+	}
+	if ref.OnUpdate != nil && ref.OnUpdate.ReferOpt != ast.ReferOptionNoOption {
+		v.appendRestored(" ", ref.OnUpdate)
+	}
+}
+
+// appendCheck 添加 CHECK 约束到 SQL 字符串, 表达式中的字面值会被参数化
+func (v *ExtractVisitor) appendCheck(expr ast.ExprNode, enforced bool) {
+	v.builder.WriteString("CHECK (")
+	expr.Accept(v)
+	v.builder.WriteString(")")
+	if !enforced {
+		v.builder.WriteString(" NOT ENFORCED")
+	}
+}
+
+// appendIndexOption 添加索引选项到 SQL 字符串, COMMENT 会被参数化
+func (v *ExtractVisitor) appendIndexOption(opt *ast.IndexOption) {
+	if opt.Comment == "" {
+		v.appendRestored(" ", opt)
+		return
+	}
+
+	option := *opt
+	option.Comment = ""
+	v.appendRestored(" ", &option)
+
+	v.builder.WriteString(" COMMENT ")
+	v.appendPlaceholder("?")
+	v.appendParam(opt.Comment, nil)
+}
+
+// appendTableOption 添加表选项到 SQL 字符串, COMMENT 会被参数化
+func (v *ExtractVisitor) appendTableOption(opt *ast.TableOption) {
+	if opt.Tp == ast.TableOptionComment {
+		v.builder.WriteString("COMMENT = ")
+		v.appendPlaceholder("?")
+		v.appendParam(opt.StrValue, nil)
+		return
+	}
+
+	v.restore(opt)
+}
@@ -0,0 +1,243 @@
+package extract
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kydenul/sql-extractor/internal/testutil"
+	"github.com/kydenul/sql-extractor/models"
+)
+
+func TestTemplatizeSQL_CreateTable(t *testing.T) {
+	t.Parallel()
+	as := assert.New(t)
+	extractor := NewExtractor()
+
+	// column definitions, constraints and table options
+	sql := "CREATE TABLE IF NOT EXISTS db_1.tb_23 (id BIGINT NOT NULL AUTO_INCREMENT, name VARCHAR(64) NOT NULL DEFAULT 'x' COMMENT 'user name', created_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP, PRIMARY KEY (id), KEY idx_name (name) COMMENT 'name idx') ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='users'"
+	template, tableInfos, params, op, pms, err := extractor.Extract(sql)
+	as.Nil(err)
+	as.Equal([]models.SQLOpType{models.SQLOperationCreate}, op)
+	as.Equal(
+		[]string{
+			"CREATE TABLE IF NOT EXISTS db_?.tb_? (id BIGINT NOT NULL AUTO_INCREMENT, name VARCHAR(64) NOT NULL DEFAULT ? COMMENT ?, created_at DATETIME DEFAULT CURRENT_TIMESTAMP() ON UPDATE CURRENT_TIMESTAMP(), PRIMARY KEY (id), INDEX idx_name (name) COMMENT ?) ENGINE = InnoDB DEFAULT CHARACTER SET = UTF8MB4 COMMENT = ?",
+		},
+		template,
+	)
+	as.Equal([][]any{{"x", "user name", "name idx", "users"}}, params)
+	as.Equal(
+		[][]*models.TableInfo{{testutil.WithShards(testutil.WithAccess(models.NewTableInfo("db_1", "tb_23", "db_?", "tb_?"), models.AccessDDL), []string{"1"}, []string{"23"})}},
+		tableInfos,
+	)
+	as.Equal([]bool{false}, pms)
+
+	// CREATE TABLE ... LIKE
+	sql = "CREATE TABLE tb_2 LIKE tb_1"
+	template, tableInfos, params, op, pms, err = extractor.Extract(sql)
+	as.Nil(err)
+	as.Equal([]models.SQLOpType{models.SQLOperationCreate}, op)
+	as.Equal([]string{"CREATE TABLE tb_? LIKE tb_?"}, template)
+	as.Equal([][]any{{}}, params)
+	as.Equal([][]*models.TableInfo{{
+		testutil.WithShards(testutil.WithAccess(models.NewTableInfo("", "tb_2", "", "tb_?"), models.AccessDDL), nil, []string{"2"}),
+		testutil.WithShards(models.NewTableInfo("", "tb_1", "", "tb_?"), nil, []string{"1"}),
+	}}, tableInfos)
+	as.Equal([]bool{false}, pms)
+
+	// CREATE TABLE ... AS SELECT
+	sql = "CREATE TABLE archive AS SELECT * FROM orders WHERE created_at < '2024-01-01'"
+	template, tableInfos, params, op, pms, err = extractor.Extract(sql)
+	as.Nil(err)
+	as.Equal([]models.SQLOpType{models.SQLOperationCreate}, op)
+	as.Equal([]string{"CREATE TABLE archive AS SELECT * FROM orders WHERE created_at lt ?"}, template)
+	as.Equal([][]any{{"2024-01-01"}}, params)
+	as.Equal([][]*models.TableInfo{{
+		testutil.WithAccess(models.NewTableInfo("", "archive", "", "archive"), models.AccessDDL),
+		models.NewTableInfo("", "orders", "", "orders"),
+	}}, tableInfos)
+	as.Equal([]bool{false}, pms)
+
+	// FOREIGN KEY and CHECK constraints, the referenced table is reported and CHECK literals are parameterized
+	sql = "CREATE TABLE child (id INT, pid INT REFERENCES p_1 (id), name VARCHAR(16) CHECK (name <> ''), CONSTRAINT fk_p FOREIGN KEY (pid) REFERENCES db_1.parent (id) ON DELETE CASCADE, CONSTRAINT chk_p CHECK (pid > 5) NOT ENFORCED, UNIQUE KEY uk_n (name))"
+	template, tableInfos, params, op, pms, err = extractor.Extract(sql)
+	as.Nil(err)
+	as.Equal([]models.SQLOpType{models.SQLOperationCreate}, op)
+	as.Equal(
+		[]string{
+			"CREATE TABLE child (id INT, pid INT REFERENCES p_? (id), name VARCHAR(16) CHECK (name ne ?), CONSTRAINT fk_p FOREIGN KEY (pid) REFERENCES db_?.parent (id) ON DELETE CASCADE, CONSTRAINT chk_p CHECK (pid gt ?) NOT ENFORCED, UNIQUE INDEX uk_n (name))",
+		},
+		template,
+	)
+	as.Equal([][]any{{"", int64(5)}}, params)
+	as.Equal([][]*models.TableInfo{{
+		testutil.WithAccess(models.NewTableInfo("", "child", "", "child"), models.AccessDDL),
+		testutil.WithShards(models.NewTableInfo("", "p_1", "", "p_?"), nil, []string{"1"}),
+		testutil.WithShards(models.NewTableInfo("db_1", "parent", "db_?", "parent"), []string{"1"}, nil),
+	}}, tableInfos)
+	as.Equal([]bool{false}, pms)
+}
+
+func TestTemplatizeSQL_AlterTable(t *testing.T) {
+	t.Parallel()
+	as := assert.New(t)
+	extractor := NewExtractor()
+
+	sql := "ALTER TABLE db_1.tb_2 ADD COLUMN age INT DEFAULT 0 COMMENT 'age' AFTER name, DROP COLUMN foo, ADD INDEX idx_age (age), MODIFY COLUMN name VARCHAR(128) NOT NULL, CHANGE COLUMN a b INT FIRST, ALTER COLUMN c SET DEFAULT 5, ALTER COLUMN d DROP DEFAULT, COMMENT = 'x'"
+	template, tableInfos, params, op, pms, err := extractor.Extract(sql)
+	as.Nil(err)
+	as.Equal([]models.SQLOpType{models.SQLOperationAlter}, op)
+	as.Equal(
+		[]string{
+			"ALTER TABLE db_?.tb_? ADD COLUMN age INT DEFAULT ? COMMENT ? AFTER name, DROP COLUMN foo, ADD INDEX idx_age (age), MODIFY COLUMN name VARCHAR(128) NOT NULL, CHANGE COLUMN a b INT FIRST, ALTER COLUMN c SET DEFAULT ?, ALTER COLUMN d DROP DEFAULT, COMMENT = ?",
+		},
+		template,
+	)
+	as.Equal([][]any{{int64(0), "age", int64(5), "x"}}, params)
+	as.Equal(
+		[][]*models.TableInfo{{testutil.WithShards(testutil.WithAccess(models.NewTableInfo("db_1", "tb_2", "db_?", "tb_?"), models.AccessDDL), []string{"1"}, []string{"2"})}},
+		tableInfos,
+	)
+	as.Equal([]bool{false}, pms)
+
+	// multiple columns
+	sql = "ALTER TABLE t ADD COLUMN (x INT, y INT)"
+	template, tableInfos, params, op, pms, err = extractor.Extract(sql)
+	as.Nil(err)
+	as.Equal([]models.SQLOpType{models.SQLOperationAlter}, op)
+	as.Equal([]string{"ALTER TABLE t ADD COLUMN (x INT, y INT)"}, template)
+	as.Equal([][]any{{}}, params)
+	as.Equal([][]*models.TableInfo{{testutil.WithAccess(models.NewTableInfo("", "t", "", "t"), models.AccessDDL)}}, tableInfos)
+	as.Equal([]bool{false}, pms)
+
+	// rename, both tables are reported
+	sql = "ALTER TABLE users RENAME TO users_bak"
+	template, tableInfos, params, op, pms, err = extractor.Extract(sql)
+	as.Nil(err)
+	as.Equal([]models.SQLOpType{models.SQLOperationAlter}, op)
+	as.Equal([]string{"ALTER TABLE users RENAME AS users_bak"}, template)
+	as.Equal([][]any{{}}, params)
+	as.Equal([][]*models.TableInfo{{
+		testutil.WithAccess(models.NewTableInfo("", "users", "", "users"), models.AccessDDL),
+		testutil.WithAccess(models.NewTableInfo("", "users_bak", "", "users_bak"), models.AccessDDL),
+	}}, tableInfos)
+	as.Equal([]bool{false}, pms)
+
+	// exchange partition, both tables are reported
+	sql = "ALTER TABLE db_1.orders EXCHANGE PARTITION p0 WITH TABLE db_1.orders_2024 WITHOUT VALIDATION"
+	template, tableInfos, params, op, pms, err = extractor.Extract(sql)
+	as.Nil(err)
+	as.Equal([]models.SQLOpType{models.SQLOperationAlter}, op)
+	as.Equal([]string{"ALTER TABLE db_?.orders EXCHANGE PARTITION p0 WITH TABLE db_?.orders_? WITHOUT VALIDATION"}, template)
+	as.Equal([][]any{{}}, params)
+	as.Equal([][]*models.TableInfo{{
+		testutil.WithAccess(testutil.WithShards(models.NewTableInfo("db_1", "orders", "db_?", "orders"), []string{"1"}, nil), models.AccessDDL),
+		testutil.WithAccess(testutil.WithShards(models.NewTableInfo("db_1", "orders_2024", "db_?", "orders_?"), []string{"1"}, []string{"2024"}), models.AccessDDL),
+	}}, tableInfos)
+	as.Equal([]bool{false}, pms)
+
+	_, _, _, _, _, err = NewExtractor(WithStrict(true)).Extract("ALTER TABLE t EXCHANGE PARTITION p1 WITH TABLE t2")
+	as.Nil(err)
+}
+
+func TestTemplatizeSQL_DropTruncateRename(t *testing.T) {
+	t.Parallel()
+	as := assert.New(t)
+	extractor := NewExtractor()
+
+	// DROP TABLE
+	sql := "DROP TABLE IF EXISTS t1, db_2.t_3"
+	template, tableInfos, params, op, pms, err := extractor.Extract(sql)
+	as.Nil(err)
+	as.Equal([]models.SQLOpType{models.SQLOperationDrop}, op)
+	as.Equal([]string{"DROP TABLE IF EXISTS t1, db_?.t_?"}, template)
+	as.Equal([][]any{{}}, params)
+	as.Equal([][]*models.TableInfo{{
+		testutil.WithAccess(models.NewTableInfo("", "t1", "", "t1"), models.AccessDDL),
+		testutil.WithShards(testutil.WithAccess(models.NewTableInfo("db_2", "t_3", "db_?", "t_?"), models.AccessDDL), []string{"2"}, []string{"3"}),
+	}}, tableInfos)
+	as.Equal([]bool{false}, pms)
+
+	// DROP VIEW
+	sql = "DROP VIEW v1"
+	template, tableInfos, params, op, pms, err = extractor.Extract(sql)
+	as.Nil(err)
+	as.Equal([]models.SQLOpType{models.SQLOperationDrop}, op)
+	as.Equal([]string{"DROP VIEW v1"}, template)
+	as.Equal([][]any{{}}, params)
+	as.Equal([][]*models.TableInfo{{testutil.WithAccess(models.NewTableInfo("", "v1", "", "v1"), models.AccessDDL)}}, tableInfos)
+	as.Equal([]bool{false}, pms)
+
+	// TRUNCATE TABLE
+	sql = "TRUNCATE TABLE tb_12"
+	template, tableInfos, params, op, pms, err = extractor.Extract(sql)
+	as.Nil(err)
+	as.Equal([]models.SQLOpType{models.SQLOperationTruncate}, op)
+	as.Equal([]string{"TRUNCATE TABLE tb_?"}, template)
+	as.Equal([][]any{{}}, params)
+	as.Equal(
+		[][]*models.TableInfo{{testutil.WithShards(testutil.WithAccess(models.NewTableInfo("", "tb_12", "", "tb_?"), models.AccessDDL), nil, []string{"12"})}},
+		tableInfos,
+	)
+	as.Equal([]bool{false}, pms)
+
+	// RENAME TABLE, both old and new names are reported
+	sql = "RENAME TABLE a TO b, db_1.c_1 TO db_1.c_2"
+	template, tableInfos, params, op, pms, err = extractor.Extract(sql)
+	as.Nil(err)
+	as.Equal([]models.SQLOpType{models.SQLOperationRename}, op)
+	as.Equal([]string{"RENAME TABLE a TO b, db_?.c_? TO db_?.c_?"}, template)
+	as.Equal([][]any{{}}, params)
+	as.Equal([][]*models.TableInfo{{
+		testutil.WithAccess(models.NewTableInfo("", "a", "", "a"), models.AccessDDL),
+		testutil.WithAccess(models.NewTableInfo("", "b", "", "b"), models.AccessDDL),
+		testutil.WithShards(testutil.WithAccess(models.NewTableInfo("db_1", "c_1", "db_?", "c_?"), models.AccessDDL), []string{"1"}, []string{"1"}),
+		testutil.WithShards(testutil.WithAccess(models.NewTableInfo("db_1", "c_2", "db_?", "c_?"), models.AccessDDL), []string{"1"}, []string{"2"}),
+	}}, tableInfos)
+	as.Equal([]bool{false}, pms)
+}
+
+func TestTemplatizeSQL_Index(t *testing.T) {
+	t.Parallel()
+	as := assert.New(t)
+	extractor := NewExtractor()
+
+	// CREATE INDEX
+	sql := "CREATE UNIQUE INDEX idx_a ON tb_1 (a, b(10) DESC) COMMENT 'x' ALGORITHM = INPLACE"
+	template, tableInfos, params, op, pms, err := extractor.Extract(sql)
+	as.Nil(err)
+	as.Equal([]models.SQLOpType{models.SQLOperationCreateIndex}, op)
+	as.Equal([]string{"CREATE UNIQUE INDEX idx_a ON tb_? (a, b(10) DESC) COMMENT ? ALGORITHM = INPLACE"}, template)
+	as.Equal([][]any{{"x"}}, params)
+	as.Equal(
+		[][]*models.TableInfo{{testutil.WithShards(testutil.WithAccess(models.NewTableInfo("", "tb_1", "", "tb_?"), models.AccessDDL), nil, []string{"1"})}},
+		tableInfos,
+	)
+	as.Equal([]bool{false}, pms)
+
+	// CREATE INDEX without options
+	sql = "CREATE INDEX idx_a ON db_1.tb_1 (a)"
+	template, tableInfos, params, op, pms, err = extractor.Extract(sql)
+	as.Nil(err)
+	as.Equal([]models.SQLOpType{models.SQLOperationCreateIndex}, op)
+	as.Equal([]string{"CREATE INDEX idx_a ON db_?.tb_? (a)"}, template)
+	as.Equal([][]any{{}}, params)
+	as.Equal(
+		[][]*models.TableInfo{{testutil.WithShards(testutil.WithAccess(models.NewTableInfo("db_1", "tb_1", "db_?", "tb_?"), models.AccessDDL), []string{"1"}, []string{"1"})}},
+		tableInfos,
+	)
+	as.Equal([]bool{false}, pms)
+
+	// DROP INDEX
+	sql = "DROP INDEX IF EXISTS idx_a ON tb_1"
+	template, tableInfos, params, op, pms, err = extractor.Extract(sql)
+	as.Nil(err)
+	as.Equal([]models.SQLOpType{models.SQLOperationDropIndex}, op)
+	as.Equal([]string{"DROP INDEX IF EXISTS idx_a ON tb_?"}, template)
+	as.Equal([][]any{{}}, params)
+	as.Equal(
+		[][]*models.TableInfo{{testutil.WithShards(testutil.WithAccess(models.NewTableInfo("", "tb_1", "", "tb_?"), models.AccessDDL), nil, []string{"1"})}},
+		tableInfos,
+	)
+	as.Equal([]bool{false}, pms)
+}
@@ -0,0 +1,32 @@
+package extract
+
+import (
+	"testing"
+
+	"github.com/pingcap/tidb/pkg/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractStatements_TiDBDigest(t *testing.T) {
+	t.Parallel()
+	as := assert.New(t)
+
+	sql := "SELECT c FROM sbtest1 WHERE id=5021; select c from sbtest1 where id = 1; DELETE FROM sbtest1 WHERE id IN (1, 2, 3)"
+
+	stmts, err := NewExtractor().ExtractStatements(sql)
+	as.Nil(err)
+	as.Empty(stmts[0].TiDBNormalizedSQL)
+	as.Empty(stmts[0].TiDBDigest)
+
+	stmts, err = NewExtractor(WithTiDBDigest(true)).ExtractStatements(sql)
+	as.Nil(err)
+	as.Len(stmts, 3)
+	as.Equal("select `c` from `sbtest1` where `id` = ?", stmts[0].TiDBNormalizedSQL)
+	as.Equal("delete from `sbtest1` where `id` in ( ... )", stmts[2].TiDBNormalizedSQL)
+	as.Equal(parser.DigestNormalized(stmts[0].TiDBNormalizedSQL).String(), stmts[0].TiDBDigest)
+	as.Len(stmts[0].TiDBDigest, 64)
+
+	// 字面值及大小写不同的语句摘要相同
+	as.Equal(stmts[0].TiDBDigest, stmts[1].TiDBDigest)
+	as.NotEqual(stmts[0].TiDBDigest, stmts[2].TiDBDigest)
+}
@@ -2,9 +2,10 @@
 // It uses TiDB's SQL parser to analyze SQL queries and produces templatized versions of the queries
 // along with extracted parameters and table information.
 //
-// The package supports multiple SQL operations including SELECT, INSERT, UPDATE, and DELETE statements.
-// It can handle complex SQL features such as JOINs, subqueries, aggregate functions, and various SQL
-// expressions (LIKE, IN, BETWEEN, etc.).
+// The package supports multiple SQL operations including SELECT, INSERT, UPDATE, and DELETE statements,
+// as well as DDL statements (CREATE/ALTER/DROP/TRUNCATE/RENAME TABLE, CREATE/DROP INDEX).
+// It can handle complex SQL features such as JOINs, subqueries, set operations (UNION, INTERSECT,
+// EXCEPT), aggregate and window functions, and various SQL expressions (LIKE, IN, BETWEEN, etc.).
 //
 // Example usage:
 //
@@ -3,16 +3,21 @@ package extract
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
+	"unicode"
 
 	"github.com/pingcap/tidb/pkg/parser"
 	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/parser/mysql"
+	"github.com/pingcap/tidb/pkg/parser/opcode"
 	"github.com/pingcap/tidb/pkg/parser/test_driver"
 	"github.com/samber/lo"
 
-	"github.com/kydenul/sql-extractor/internal/models"
+	"github.com/kydenul/sql-extractor/internal/literal"
+	"github.com/kydenul/sql-extractor/models"
 )
 
 const (
@@ -23,117 +28,345 @@ const (
 type Extractor struct {
 	parser *parser.Parser
 
+	cfg config
+
 	pool sync.Pool
 }
 
-func NewExtractor() *Extractor {
-	return &Extractor{
+func NewExtractor(opts ...Option) *Extractor {
+	e := &Extractor{
 		parser: parser.New(),
-		pool: sync.Pool{
-			New: func() any {
-				return &ExtractVisitor{
-					builder:    &strings.Builder{},
-					params:     make([]any, 0, paramsMaxCount),
-					tableInfos: make([]*models.TableInfo, 0, paramsMaxCount),
-					opType:     models.SQLOperationUnknown,
-				}
-			},
+		cfg:    defaultConfig(),
+	}
+
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	e.parser.SetSQLMode(e.cfg.sqlMode)
+
+	e.pool = sync.Pool{
+		New: func() any {
+			return &ExtractVisitor{
+				builder:    &strings.Builder{},
+				params:     make([]any, 0, paramsMaxCount),
+				tableInfos: make([]*models.TableInfo, 0, paramsMaxCount),
+				opType:     models.SQLOperationUnknown,
+				cfg:        &e.cfg,
+			}
 		},
 	}
+
+	return e
 }
 
 // Extract returns the templatized SQL, table info, parameters, operation type
 // and whether the SQL contains parameter markers.
 // It supports multiple SQL statements separated by semicolons.
+//
+// The results are parallel slices indexed by statement, see ExtractStatements
+// for the per-statement form.
 func (e *Extractor) Extract(sql string) (
 	[]string, [][]*models.TableInfo, [][]any, []models.SQLOpType, []bool, error,
 ) {
+	stmts, err := e.ExtractStatements(sql)
+	if err != nil {
+		return nil, nil, nil, nil, nil, err
+	}
+
+	var (
+		allTemplatizedSQL = make([]string, 0, len(stmts))
+		allParams         = make([][]any, 0, len(stmts))
+		allTableInfos     = make([][]*models.TableInfo, 0, len(stmts))
+		opType            = make([]models.SQLOpType, 0, len(stmts))
+		hasParamMarker    = make([]bool, 0, len(stmts))
+	)
+
+	for _, stmt := range stmts {
+		allTemplatizedSQL = append(allTemplatizedSQL, stmt.TemplatizedSQL)
+		allParams = append(allParams, stmt.Params)
+		allTableInfos = append(allTableInfos, stmt.TableInfos)
+		opType = append(opType, stmt.OpType)
+		hasParamMarker = append(hasParamMarker, stmt.HasParamMarker)
+	}
+
+	return allTemplatizedSQL, allTableInfos, allParams, opType, hasParamMarker, nil
+}
+
+// ExtractStatements returns the extraction result of each SQL statement,
+// in the order they appear in the raw SQL.
+// It supports multiple SQL statements separated by semicolons.
+//
+// The Hash of the returned statements is left empty.
+func (e *Extractor) ExtractStatements(sql string) ([]*models.Statement, error) {
 	if sql == "" {
-		return nil, nil, nil, nil, nil, errors.New("empty SQL statement")
+		return nil, errors.New("empty SQL statement")
 	}
 
-	stmts, _, err := e.parser.Parse(sql, "", "")
+	stmts, _, err := e.parser.Parse(sql, e.cfg.charset, e.cfg.collation)
 	if err != nil {
-		return nil, nil, nil, nil, nil, err
+		return nil, err
 	}
 
 	if len(stmts) == 0 {
-		return nil, nil, nil, nil, nil, errors.New("no valid SQL statements found")
+		return nil, errors.New("no valid SQL statements found")
 	}
 
 	// Handle multiple statements
 	var (
-		allTemplatizedSQL = make([]string, 0, len(stmts))
-		allParams         = make([][]any, 0, len(stmts))
-		allTableInfos     = make([][]*models.TableInfo, 0, len(stmts))
-		opType            = make([]models.SQLOpType, 0, len(stmts))
-		hasParamMarker    = make([]bool, 0, len(stmts))
+		results = make([]*models.Statement, 0, len(stmts))
+		cursor  int // 已定位语句在原始 SQL 中的结束位置
 	)
 
 	for idx := range stmts {
-		templatedSQL, tableInfos, params, op, paramMarker, err := e.extractOneStmt(stmts[idx])
+		result, keptLiterals, err := e.extractOneStmt(stmts[idx])
 		if err != nil {
-			return nil, nil, nil, nil, nil, fmt.Errorf(
+			return nil, fmt.Errorf(
 				"error processing statement %d: %w",
 				idx+1,
 				err,
 			)
 		}
 
-		allTemplatizedSQL = append(allTemplatizedSQL, templatedSQL)
-		allParams = append(allParams, params)
-		allTableInfos = append(allTableInfos, tableInfos)
-		opType = append(opType, op)
-		hasParamMarker = append(hasParamMarker, paramMarker)
+		result.Index = idx
+		result.Text, result.StartOffset, result.EndOffset = locateStmtText(sql, stmts[idx].Text(), cursor)
+		cursor = max(cursor, result.EndOffset)
+		fillParamTexts(sql, result, keptLiterals)
+
+		if e.cfg.tidbDigest {
+			normalized, digest := parser.NormalizeDigest(result.Text)
+			result.TiDBNormalizedSQL, result.TiDBDigest = normalized, digest.String()
+		}
+
+		for i := range result.Warnings {
+			w := &result.Warnings[i]
+			w.StmtIndex = idx
+			if w.Offset < result.StartOffset { // 节点无位置信息
+				w.Offset = result.StartOffset
+			}
+
+			e.cfg.logger.Warn("[SQL Templatize] unhandled node",
+				"stmt_index", w.StmtIndex, "node_type", w.NodeType, "offset", w.Offset,
+				"clause", w.Clause.String(), "details", w.Message)
+		}
+
+		results = append(results, result)
 	}
 
-	return allTemplatizedSQL, allTableInfos, allParams, opType, hasParamMarker, nil
+	if e.cfg.strict {
+		warnings := lo.FlatMap(results, func(s *models.Statement, _ int) []models.Warning { return s.Warnings })
+		if len(warnings) > 0 {
+			return nil, &UnhandledNodeError{Warnings: warnings}
+		}
+	}
+
+	return results, nil
 }
 
-// extractOneStmt handles a single SQL statement
-func (e *Extractor) extractOneStmt(stmt ast.StmtNode) (
-	string, []*models.TableInfo, []any, models.SQLOpType, bool, error,
-) {
+// locateStmtText 在原始 SQL 中从 cursor 开始定位语句文本,
+// 返回去除首尾空白及结尾分号后的文本, 以及其在原始 SQL 中的起止字节偏移 [start, end)
+func locateStmtText(sql, text string, cursor int) (string, int, int) {
+	start := cursor
+	if idx := strings.Index(sql[cursor:], text); idx >= 0 {
+		start += idx
+	}
+
+	trimmed := strings.TrimLeftFunc(text, unicode.IsSpace)
+	start += len(text) - len(trimmed)
+
+	trimmed = strings.TrimRightFunc(trimmed, unicode.IsSpace)
+	trimmed = strings.TrimSuffix(trimmed, ";")
+	trimmed = strings.TrimRightFunc(trimmed, unicode.IsSpace)
+
+	return trimmed, start, start + len(trimmed)
+}
+
+// extractOneStmt handles a single SQL statement,
+// it also returns the offsets of the literals kept in the templatized SQL
+func (e *Extractor) extractOneStmt(stmt ast.StmtNode) (*models.Statement, []int, error) {
 	v, ok := e.pool.Get().(*ExtractVisitor)
 	if !ok {
-		return "", nil, nil, models.SQLOperationUnknown, false,
-			errors.New("failed to get ExtractVisitor from pool")
+		return nil, nil, errors.New("failed to get ExtractVisitor from pool")
 	}
 
 	defer func() {
 		v.builder.Reset()
 		v.params = v.params[:0]
+		v.paramInfos = v.paramInfos[:0]
+		v.keptLiterals = v.keptLiterals[:0]
+		v.placeholders = 0
 		v.tableInfos = v.tableInfos[:0]
-		v.inAggrFunc = false
+		v.literalCtx = 0
+		v.cteNames = v.cteNames[:0]
+		v.aliases = v.aliases[:0]
+		v.columns = v.columns[:0]
+		v.predicates = v.predicates[:0]
+		v.logicOps = v.logicOps[:0]
+		v.access = models.AccessNone
+		v.writeTargets = nil
 		v.opType = models.SQLOperationUnknown
+		v.hasParamMarker = false
+		v.clause = models.ClauseNone
+		v.warnings = nil
 
 		e.pool.Put(v)
 	}()
 
 	stmt.Accept(v)
 
-	return v.builder.String(),
-		lo.UniqBy(v.tableInfos, func(t *models.TableInfo) string {
-			if t.Schema() == "" {
-				return t.TableName()
-			}
+	// 按首次出现的顺序去重表信息, 同一表多次出现时合并其访问方式
+	tableInfos := make([]*models.TableInfo, 0, len(v.tableInfos))
+	positions := make(map[string]int, len(v.tableInfos))
+	for _, ti := range v.tableInfos {
+		// CTE 引用不是物理表，不计入表信息
+		if ti.IsCTE() {
+			continue
+		}
+
+		if idx, ok := positions[tableKey(ti)]; ok {
+			tableInfos[idx].SetAccess(tableInfos[idx].Access().Merge(ti.Access()))
+			continue
+		}
+
+		positions[tableKey(ti)] = len(tableInfos)
+		tableInfos = append(tableInfos, ti)
+	}
+
+	result := &models.Statement{
+		TemplatizedSQL: v.builder.String(),
+		// visitor 会被复用, 参数需拷贝
+		Params:         append(make([]any, 0, len(v.params)), v.params...),
+		TableInfos:     tableInfos,
+		OpType:         v.opType,
+		HasParamMarker: v.hasParamMarker,
+		Aliases:        canonicalAliases(v.aliases, tableInfos),
+		Warnings:       v.warnings,
+	}
+	columns := canonicalColumns(v.columns, result)
+	result.Columns = lo.Reject(columns, func(_ models.Column, idx int) bool { return v.columns[idx].fieldAlias })
+
+	// 引用 SELECT 列表别名的比较 (e.g. HAVING cnt > ?) 没有表的列, 不作为谓词, 参数也不关联列
+	predicates := lo.Reject(v.predicates, func(p predicateRef, _ int) bool { return v.columns[p.column].fieldAlias })
+	result.Predicates = canonicalPredicates(predicates, columns)
+	paramInfos := lo.Map(v.paramInfos, func(p paramRef, _ int) paramRef {
+		if p.column >= 0 && v.columns[p.column].fieldAlias {
+			p.column = -1
+		}
+		return p
+	})
+	result.ParamInfos = canonicalParamInfos(paramInfos, columns)
+
+	// visitor 会被复用, 需拷贝
+	return result, append([]int(nil), v.keptLiterals...), nil
+}
+
+// tableKey 返回去重表信息所用的键, 即 schema.table
+func tableKey(t *models.TableInfo) string {
+	if t.Schema() == "" {
+		return t.TableName()
+	}
+
+	return t.Schema() + "." + t.TableName()
+}
+
+// canonicalAliases 拷贝别名, 并将其指向的表替换为去重后的表信息, 使其与 Statement.TableInfos 中的元素相同
+func canonicalAliases(aliases []models.Alias, tableInfos []*models.TableInfo) []models.Alias {
+	if len(aliases) == 0 {
+		return nil
+	}
+
+	canonical := lo.KeyBy(tableInfos, tableKey)
+
+	result := make([]models.Alias, 0, len(aliases))
+	for _, alias := range aliases {
+		alias.Tables = lo.Uniq(lo.Map(alias.Tables, func(t *models.TableInfo, _ int) *models.TableInfo {
+			return canonical[tableKey(t)]
+		}))
+		result = append(result, alias)
+	}
+
+	return result
+}
+
+// canonicalColumns 拷贝列, 限定列按其限定名解析所属表, 未限定列的所属表替换为去重后的表信息,
+// 返回的列与 columns 一一对应
+func canonicalColumns(columns []columnRef, stmt *models.Statement) []models.Column {
+	if len(columns) == 0 {
+		return nil
+	}
+
+	canonical := lo.KeyBy(stmt.TableInfos, tableKey)
+
+	result := make([]models.Column, 0, len(columns))
+	for _, col := range columns {
+		if col.Table != "" {
+			col.Tables = stmt.ResolveAlias(col.Qualifier())
+		} else {
+			col.Tables = lo.Uniq(lo.FilterMap(col.Tables, func(t *models.TableInfo, _ int) (*models.TableInfo, bool) {
+				ti, ok := canonical[tableKey(t)]
+				return ti, ok
+			}))
+		}
+		result = append(result, col.Column)
+	}
+
+	return result
+}
 
-			return t.Schema() + "." + t.TableName()
-		}),
-		v.params,
-		v.opType,
-		v.hasParamMarker,
-		nil
+// canonicalPredicates 拷贝谓词, 并填充其比较的列, columns 为 canonicalColumns 的结果
+func canonicalPredicates(predicates []predicateRef, columns []models.Column) []models.Predicate {
+	if len(predicates) == 0 {
+		return nil
+	}
+
+	result := make([]models.Predicate, 0, len(predicates))
+	for _, p := range predicates {
+		p.Column = columns[p.column]
+		result = append(result, p.Predicate)
+	}
+
+	return result
+}
+
+// columnRef 语句中引用的列
+type columnRef struct {
+	models.Column
+
+	// 未限定表名的列是否已归属于其所在的查询块, 查询块没有表时 (e.g. 关联子查询 SELECT a) 由外层查询块确定
+	resolved bool
+
+	fieldAlias bool // 引用 SELECT 列表的别名而非表的列, 不计入 Statement.Columns
+}
+
+// predicateRef 语句中的谓词, column 为其比较的列在 columns 中的位置
+type predicateRef struct {
+	models.Predicate
+
+	column int
 }
 
 // ExtractVisitor 实现 ast.Visitor 接口
 type ExtractVisitor struct {
 	builder        *strings.Builder
 	params         []any
-	inAggrFunc     bool
+	paramInfos     []paramRef      // 参数的位置、子句及绑定的列, 与 params 一一对应
+	keptLiterals   []int           // 保留在模板中的字面值在原始 SQL 中的位置
+	placeholders   int             // 已添加的参数占位符数量
+	literalCtx     LiteralPosition // 当前所在的字面值位置 (聚合函数、CASE 表达式、INTERVAL)
 	tableInfos     []*models.TableInfo
+	cteNames       []string          // 当前作用域内可见的 CTE 名称 (小写)
+	aliases        []models.Alias    // 表别名及 CTE 名称, 按声明顺序
+	columns        []columnRef       // 引用的列, 按出现顺序
+	predicates     []predicateRef    // 列与值的比较, 按出现顺序
+	logicOps       []models.LogicOp  // 当前所在的逻辑运算符, 由外到内
+	access         models.AccessMode // 当前表名的访问方式
+	writeTargets   []string          // 多表 UPDATE / DELETE 中被修改的表, 见 writeTargetKey, * 表示全部
 	opType         models.SQLOpType
-	hasParamMarker bool // 标记该 SQL 语句是否包含参数占位符
+	hasParamMarker bool    // 标记该 SQL 语句是否包含参数占位符
+	cfg            *config // 提取行为配置, 由 Extractor 持有
+
+	clause   models.Clause    // 当前所在的子句
+	warnings []models.Warning // 无法完整模板化的节点
 }
 
 // 避免重复字符串操作
@@ -143,6 +376,34 @@ var joinTypeMap = map[ast.JoinType]string{
 	ast.CrossJoin: " CROSS JOIN ",
 }
 
+// 合法 MySQL 语法的运算符, 用于 RenderModeSQL
+var sqlOperatorMap = map[opcode.Op]string{
+	opcode.LogicAnd:   "AND",
+	opcode.LogicOr:    "OR",
+	opcode.LogicXor:   "XOR",
+	opcode.LeftShift:  "<<",
+	opcode.RightShift: ">>",
+	opcode.GE:         ">=",
+	opcode.LE:         "<=",
+	opcode.EQ:         "=",
+	opcode.NE:         "!=",
+	opcode.LT:         "<",
+	opcode.GT:         ">",
+	opcode.NullEQ:     "<=>",
+	opcode.Plus:       "+",
+	opcode.Minus:      "-",
+	opcode.Mul:        "*",
+	opcode.Div:        "/",
+	opcode.IntDiv:     "DIV",
+	opcode.Mod:        "MOD",
+	opcode.And:        "&",
+	opcode.Or:         "|",
+	opcode.Xor:        "^",
+	opcode.Not:        "NOT",
+	opcode.Not2:       "!",
+	opcode.BitNeg:     "~",
+}
+
 // Enter implement ast.Visitor interface. It handles ast.Node
 //
 // Return: nil, true - 不继续遍历， n, false - 继续遍历
@@ -161,7 +422,7 @@ func (v *ExtractVisitor) Enter(n ast.Node) (ast.Node, bool) {
 		v.handleValueExpr(node)
 	case *test_driver.ParamMarkerExpr:
 		// ParamMarkerExpr 仅表示占位符，实际参数值由父节点（如 ValueExpr, Assignment）处理
-		v.builder.WriteString("?")
+		v.appendPlaceholder("?")
 		v.hasParamMarker = true
 	case *ast.BinaryOperationExpr: // e.g 1+1, and
 		v.handleBinaryOperationExpr(node)
@@ -171,6 +432,10 @@ func (v *ExtractVisitor) Enter(n ast.Node) (ast.Node, bool) {
 	// 2. SQL 语句层
 	case *ast.SelectStmt:
 		v.handleSelectStmt(node)
+	case *ast.SetOprStmt:
+		v.handleSetOprStmt(node)
+	case *ast.SetOprSelectList:
+		v.handleSetOprSelectList(node)
 	case *ast.InsertStmt:
 		v.handleInsertStmt(node)
 	case *ast.UpdateStmt:
@@ -182,7 +447,23 @@ func (v *ExtractVisitor) Enter(n ast.Node) (ast.Node, bool) {
 	case *ast.ShowStmt:
 		v.handleShowStmt(node)
 
-	// 3. 表结构层 - 表引用和连接
+	// 3. DDL 语句层
+	case *ast.CreateTableStmt:
+		v.handleCreateTableStmt(node)
+	case *ast.AlterTableStmt:
+		v.handleAlterTableStmt(node)
+	case *ast.DropTableStmt:
+		v.handleDropTableStmt(node)
+	case *ast.TruncateTableStmt:
+		v.handleTruncateTableStmt(node)
+	case *ast.RenameTableStmt:
+		v.handleRenameTableStmt(node)
+	case *ast.CreateIndexStmt:
+		v.handleCreateIndexStmt(node)
+	case *ast.DropIndexStmt:
+		v.handleDropIndexStmt(node)
+
+	// 4. 表结构层 - 表引用和连接
 	case *ast.TableSource:
 		v.handleTableSource(node)
 	case *ast.Join:
@@ -190,7 +471,7 @@ func (v *ExtractVisitor) Enter(n ast.Node) (ast.Node, bool) {
 	case *ast.OnCondition:
 		v.handleOnCondition(node)
 
-	// 4. 条件表达式层 - WHERE/HAVING 子句中的条件
+	// 5. 条件表达式层 - WHERE/HAVING 子句中的条件
 	case *ast.PatternInExpr:
 		v.handlePatternInExpr(node)
 	case *ast.PatternRegexpExpr:
@@ -206,20 +487,30 @@ func (v *ExtractVisitor) Enter(n ast.Node) (ast.Node, bool) {
 	case *ast.CompareSubqueryExpr:
 		v.handleCompareSubqueryExpr(node)
 
-	// 5. 函数和聚合层
+	// 6. 函数和聚合层
 	case *ast.FuncCallExpr:
 		v.handleFuncCallExpr(node)
+	case *ast.FuncCastExpr:
+		v.handleFuncCastExpr(node)
+	case *ast.GetFormatSelectorExpr:
+		v.handleGetFormatSelectorExpr(node)
 	case *ast.AggregateFuncExpr:
-		old := v.inAggrFunc
-		v.inAggrFunc = true
-		defer func() { v.inAggrFunc = old }()
+		defer v.enterLiteralCtx(LiteralAggrFunc)()
 		v.handleAggregateFuncExpr(node)
+	case *ast.WindowFuncExpr:
+		v.handleWindowFuncExpr(node)
+	case *ast.WindowSpec:
+		v.handleWindowSpec(node)
+	case *ast.FrameClause:
+		v.handleFrameClause(node)
+	case *ast.FrameBound:
+		v.handleFrameBound(node)
 	case *ast.UnaryOperationExpr:
 		v.handleUnaryOperationExpr(node)
 	case *ast.TimeUnitExpr:
 		v.handleTimeUnitExpr(node)
 
-	// 6. 修饰语层 - ORDER BY, LIMIT 等
+	// 7. 修饰语层 - ORDER BY, LIMIT 等
 	case *ast.ByItem:
 		v.handleByItem(node)
 	case *ast.Limit:
@@ -229,7 +520,7 @@ func (v *ExtractVisitor) Enter(n ast.Node) (ast.Node, bool) {
 	case *ast.ValuesExpr:
 		v.handleValuesExpr(node)
 
-	// 7. 子查询层 - 最复杂的查询结构
+	// 8. 子查询层 - 最复杂的查询结构
 	case *ast.SubqueryExpr:
 		v.handleSubqueryExpr(node)
 	case *ast.IsNullExpr:
@@ -237,7 +528,7 @@ func (v *ExtractVisitor) Enter(n ast.Node) (ast.Node, bool) {
 	case *ast.ExistsSubqueryExpr:
 		v.handleExistsSubqueryExpr(node)
 
-	// 8. 处理 DEFAULT 表达式
+	// 9. 处理 DEFAULT 表达式
 	case *ast.DefaultExpr:
 		v.handleDefaultExpr(node)
 
@@ -247,12 +538,17 @@ func (v *ExtractVisitor) Enter(n ast.Node) (ast.Node, bool) {
 	case *ast.PositionExpr:
 		v.handlePositionExpr(node)
 
+	case *ast.RowExpr:
+		v.handleRowExpr(node)
+	case *ast.VariableExpr:
+		v.handleVariableExpr(node)
+	case *ast.MatchAgainst:
+		v.handleMatchAgainst(node)
+	case *ast.SetCollationExpr:
+		v.handleSetCollationExpr(node)
+
 	default:
-		// FIXME RowExpr
-		// FIXME VariableExpr
-		// FIXME MatchAgainst
-		// FIXME SetCollationExpr
-		v.logError(fmt.Sprintf("Enter ast.Node type: %T", node))
+		v.reportUnhandled(node, "unhandled node type")
 	}
 
 	return n, true
@@ -271,13 +567,23 @@ func (v *ExtractVisitor) handleSelectStmt(node *ast.SelectStmt) {
 	if v.opType == models.SQLOperationUnknown {
 		v.opType = models.SQLOperationSelect
 	}
+	defer v.enterStmtScope()()
+
+	// WITH 子句
+	if node.With != nil {
+		defer v.popCTENames(len(v.cteNames))
+		v.handleWithClause(node.With)
+	}
+	colStart := len(v.columns)
 
 	v.builder.WriteString("SELECT ")
+	v.clause = models.ClauseSelect
 
 	// DISTINCT 关键字
 	if node.Distinct {
 		v.builder.WriteString("DISTINCT ")
 	}
+	v.appendSelectOpts(node.SelectStmtOpts)
 
 	// 处理 SELECT 列表
 	if node.Fields != nil {
@@ -299,6 +605,11 @@ func (v *ExtractVisitor) handleSelectStmt(node *ast.SelectStmt) {
 				}
 
 				v.builder.WriteString("*")
+				v.appendColumn(&ast.ColumnName{
+					Schema: node.Fields.Fields[idx].WildCard.Schema,
+					Table:  node.Fields.Fields[idx].WildCard.Table,
+					Name:   ast.NewCIStr("*"),
+				}, models.AccessRead)
 			} else {
 				node.Fields.Fields[idx].Expr.Accept(v)
 
@@ -312,22 +623,28 @@ func (v *ExtractVisitor) handleSelectStmt(node *ast.SelectStmt) {
 	}
 
 	// FROM 子句
+	var tables []*models.TableInfo
 	if node.From != nil {
 		v.builder.WriteString(" FROM ")
+		v.clause = models.ClauseFrom
 		if node.From.TableRefs != nil {
+			start := len(v.tableInfos)
 			node.From.TableRefs.Accept(v)
+			tables = v.resolveTables(v.tableInfos[start:])
 		}
 	}
 
 	// WHERE 子句
 	if node.Where != nil {
 		v.builder.WriteString(" WHERE ")
+		v.clause = models.ClauseWhere
 		node.Where.Accept(v)
 	}
 
 	// GROUP BY 子句
 	if node.GroupBy != nil {
 		v.builder.WriteString(" GROUP BY ")
+		v.clause = models.ClauseGroupBy
 		for idx, item := range node.GroupBy.Items {
 			if idx > 0 {
 				v.builder.WriteString(", ")
@@ -335,25 +652,28 @@ func (v *ExtractVisitor) handleSelectStmt(node *ast.SelectStmt) {
 
 			item.Accept(v)
 		}
+
+		if node.GroupBy.Rollup {
+			v.builder.WriteString(" WITH ROLLUP")
+		}
 	}
 
 	// HAVING 子句
 	if node.Having != nil && node.Having.Expr != nil {
 		v.builder.WriteString(" HAVING ")
+		v.clause = models.ClauseHaving
+		node.Having.Expr.Accept(v)
+	}
 
-		switch expr := node.Having.Expr.(type) {
-		case *ast.BinaryOperationExpr:
-			expr.Accept(v)
-
-		default:
-			v.logError(fmt.Sprintf("Having.Expr type: %T", expr))
-			expr.Accept(v)
-		}
+	// WINDOW 子句
+	if len(node.WindowSpecs) > 0 {
+		v.appendWindowClause(node.WindowSpecs)
 	}
 
 	// ORDER BY 子句
 	if node.OrderBy != nil {
 		v.builder.WriteString(" ORDER BY ")
+		v.clause = models.ClauseOrderBy
 		for idx, item := range node.OrderBy.Items {
 			if idx > 0 {
 				v.builder.WriteString(", ")
@@ -367,27 +687,410 @@ func (v *ExtractVisitor) handleSelectStmt(node *ast.SelectStmt) {
 	if node.Limit != nil {
 		node.Limit.Accept(v)
 	}
+
+	// FOR UPDATE / FOR SHARE 子句
+	if node.LockInfo != nil {
+		v.appendSelectLock(node.LockInfo)
+	}
+
+	if node.SelectIntoOpt != nil {
+		v.reportUnhandled(node.SelectIntoOpt, "unhandled select into")
+	}
+
+	v.markFieldAliases(colStart, node.Fields)
+	if len(tables) > 0 {
+		v.resolveColumns(colStart, tables)
+	}
 }
 
-// INSERT 语句
+// appendSelectOpts 添加 SELECT 的选项 (HIGH_PRIORITY, SQL_CALC_FOUND_ROWS ...), 优化器提示无法模板化
+func (v *ExtractVisitor) appendSelectOpts(opts *ast.SelectStmtOpts) {
+	if opts == nil {
+		return
+	}
+
+	if opts.Priority != mysql.NoPriority {
+		v.builder.WriteString(mysql.Priority2Str[opts.Priority])
+		v.builder.WriteString(" ")
+	}
+	if opts.StraightJoin {
+		v.builder.WriteString("STRAIGHT_JOIN ")
+	}
+	if opts.SQLSmallResult {
+		v.builder.WriteString("SQL_SMALL_RESULT ")
+	}
+	if opts.SQLBigResult {
+		v.builder.WriteString("SQL_BIG_RESULT ")
+	}
+	if opts.SQLBufferResult {
+		v.builder.WriteString("SQL_BUFFER_RESULT ")
+	}
+	if !opts.SQLCache {
+		v.builder.WriteString("SQL_NO_CACHE ")
+	}
+	if opts.CalcFoundRows {
+		v.builder.WriteString("SQL_CALC_FOUND_ROWS ")
+	}
+
+	for _, hint := range opts.TableHints {
+		v.reportUnhandled(hint, "unhandled optimizer hint")
+	}
+}
+
+// appendSelectLock 添加锁定读子句到 SQL 字符串, LOCK IN SHARE MODE 与 FOR SHARE 等价
+//
+// e.g. FOR UPDATE OF t1, t2 NOWAIT
+func (v *ExtractVisitor) appendSelectLock(node *ast.SelectLockInfo) {
+	var lock, wait string
+	switch node.LockType {
+	case ast.SelectLockNone:
+		return
+	case ast.SelectLockForUpdate:
+		lock = " FOR UPDATE"
+	case ast.SelectLockForShare:
+		lock = " FOR SHARE"
+	case ast.SelectLockForUpdateNoWait:
+		lock, wait = " FOR UPDATE", " NOWAIT"
+	case ast.SelectLockForUpdateWaitN:
+		lock, wait = " FOR UPDATE", " WAIT "+strconv.FormatUint(node.WaitSec, 10)
+	case ast.SelectLockForShareNoWait:
+		lock, wait = " FOR SHARE", " NOWAIT"
+	case ast.SelectLockForUpdateSkipLocked:
+		lock, wait = " FOR UPDATE", " SKIP LOCKED"
+	case ast.SelectLockForShareSkipLocked:
+		lock, wait = " FOR SHARE", " SKIP LOCKED"
+	default:
+		v.reportUnhandled(node, "unhandled select lock: "+node.LockType.String())
+		return
+	}
+
+	v.builder.WriteString(lock)
+	for idx, table := range node.Tables {
+		v.builder.WriteString(lo.Ternary(idx == 0, " OF ", ", "))
+
+		// 与 FROM 中的表名保持一致的分库分表模板化
+		if table.Schema.O != "" {
+			schema, _ := templateShardName(v.conf().schemaShard, table.Schema.O)
+			v.builder.WriteString(schema)
+			v.builder.WriteString(".")
+		}
+		name, _ := templateShardName(v.conf().tableShard, table.Name.O)
+		v.builder.WriteString(name)
+	}
+	v.builder.WriteString(wait)
+}
+
+// handleSetOprStmt 处理 UNION / INTERSECT / EXCEPT 集合操作语句
+//
+// e.g. (SELECT a FROM t1 LIMIT 1) UNION ALL SELECT b FROM t2 ORDER BY a LIMIT 10
+func (v *ExtractVisitor) handleSetOprStmt(node *ast.SetOprStmt) {
+	if v.opType == models.SQLOperationUnknown {
+		v.opType = models.SQLOperationSelect
+	}
+	defer v.enterStmtScope()()
+
+	// WITH 子句
+	if node.With != nil {
+		defer v.popCTENames(len(v.cteNames))
+		v.handleWithClause(node.With)
+	}
+
+	if node.IsInBraces {
+		v.builder.WriteString("(")
+	}
+
+	if node.SelectList != nil {
+		node.SelectList.Accept(v)
+	}
+
+	// 集合操作整体的 ORDER BY 子句, 未限定的列引用集合操作的结果列
+	if node.OrderBy != nil {
+		start := len(v.columns)
+		v.appendOrderBy(node.OrderBy)
+		v.resolveColumns(start, nil)
+	}
+
+	// 集合操作整体的 LIMIT 子句
+	if node.Limit != nil {
+		node.Limit.Accept(v)
+	}
+
+	if node.IsInBraces {
+		v.builder.WriteString(")")
+	}
+}
+
+// handleSetOprSelectList 处理集合操作的各个分支
+//
+// 分支可以是 SELECT 语句，也可以是带括号的嵌套集合操作，例如:
+// SELECT 1 UNION (SELECT 2 EXCEPT SELECT 3)
+func (v *ExtractVisitor) handleSetOprSelectList(node *ast.SetOprSelectList) {
+	defer v.enterStmtScope()()
+
+	// WITH 子句
+	if node.With != nil {
+		defer v.popCTENames(len(v.cteNames))
+		v.handleWithClause(node.With)
+	}
+
+	for idx := range node.Selects {
+		switch sel := node.Selects[idx].(type) {
+		case *ast.SelectStmt:
+			if idx > 0 {
+				v.appendSetOprType(sel.AfterSetOperator)
+			}
+
+			sel.Accept(v)
+
+		case *ast.SetOprSelectList:
+			if idx > 0 {
+				v.appendSetOprType(sel.AfterSetOperator)
+			}
+
+			v.builder.WriteString("(")
+			sel.Accept(v)
+			v.builder.WriteString(")")
+
+		default:
+			v.reportUnhandled(sel, "unhandled set operation branch")
+			sel.Accept(v)
+		}
+	}
+
+	// 括号内分支的 ORDER BY 子句
+	if node.OrderBy != nil {
+		start := len(v.columns)
+		v.appendOrderBy(node.OrderBy)
+		v.resolveColumns(start, nil)
+	}
+
+	// 括号内分支的 LIMIT 子句
+	if node.Limit != nil {
+		node.Limit.Accept(v)
+	}
+}
+
+// handleWithClause 处理 WITH [RECURSIVE] 子句中的公共表表达式 (CTE)
+//
+// CTE 名称在其作用域内可见，引用 CTE 的表名不会被视为物理表。
+// 调用方需在语句处理结束后调用 popCTENames 恢复作用域。
+func (v *ExtractVisitor) handleWithClause(node *ast.WithClause) {
+	v.builder.WriteString("WITH ")
+	v.clause = models.ClauseWith
+	if node.IsRecursive {
+		v.builder.WriteString("RECURSIVE ")
+	}
+
+	for idx, cte := range node.CTEs {
+		if idx > 0 {
+			v.builder.WriteString(", ")
+		}
+
+		// 递归 CTE 在自身定义中可见
+		if node.IsRecursive {
+			v.cteNames = append(v.cteNames, cte.Name.L)
+		}
+
+		v.builder.WriteString(cte.Name.O)
+
+		if len(cte.ColNameList) > 0 {
+			v.builder.WriteString(" (")
+			for jdx := range cte.ColNameList {
+				if jdx > 0 {
+					v.builder.WriteString(", ")
+				}
+
+				v.builder.WriteString(cte.ColNameList[jdx].O)
+			}
+			v.builder.WriteString(")")
+		}
+
+		v.builder.WriteString(" AS ")
+		start := len(v.tableInfos)
+		if cte.Query != nil {
+			cte.Query.Accept(v) // call handleSubqueryExpr()
+		}
+
+		v.aliases = append(v.aliases, models.Alias{
+			Name:   cte.Name.O,
+			Kind:   models.AliasCTE,
+			Tables: v.resolveTables(v.tableInfos[start:]),
+		})
+
+		// 非递归 CTE 仅对其后的 CTE 及主语句可见
+		if !node.IsRecursive {
+			v.cteNames = append(v.cteNames, cte.Name.L)
+		}
+	}
+
+	v.builder.WriteString(" ")
+}
+
+// popCTENames 将 CTE 作用域恢复到 n 个名称
+func (v *ExtractVisitor) popCTENames(n int) { v.cteNames = v.cteNames[:n] }
+
+// restoreClause 恢复当前所在的子句, 用于语句处理结束时避免子查询的子句外泄
+func (v *ExtractVisitor) restoreClause(clause models.Clause) { v.clause = clause }
+
+// enterStmtScope 进入 (子) 语句, 返回恢复外层子句及字面值上下文的函数, 避免子查询的子句及上下文外泄
+//
+// (子) 语句中的表默认为读取, 由 INSERT、UPDATE、DELETE 语句标记被修改的表; 子查询中的谓词不受外层逻辑运算符影响
+func (v *ExtractVisitor) enterStmtScope() func() {
+	clause, literalCtx, access, writeTargets, logicOps := v.clause, v.literalCtx, v.access, v.writeTargets, v.logicOps
+	v.literalCtx, v.access, v.writeTargets, v.logicOps = 0, models.AccessRead, nil, nil
+
+	return func() {
+		v.clause, v.literalCtx, v.access, v.writeTargets, v.logicOps = clause, literalCtx, access, writeTargets, logicOps
+	}
+}
+
+// enterLiteralCtx 进入字面值位置 (聚合函数、CASE 表达式、INTERVAL 等), 返回恢复外层上下文的函数
+func (v *ExtractVisitor) enterLiteralCtx(pos LiteralPosition) func() {
+	literalCtx := v.literalCtx
+	v.literalCtx |= pos
+
+	return func() { v.literalCtx = literalCtx }
+}
+
+// keepLiteral 判断当前位置的字面值是否按配置直接输出而不参数化
+func (v *ExtractVisitor) keepLiteral() bool {
+	keep := v.conf().keepLiterals
+	if v.literalCtx&keep != 0 {
+		return true
+	}
+
+	switch v.clause {
+	case models.ClauseSelect:
+		return keep&LiteralSelectList != 0
+	case models.ClauseGroupBy:
+		return keep&LiteralGroupBy != 0
+	case models.ClauseLimit:
+		return keep&LiteralLimit != 0
+	default:
+		return false
+	}
+}
+
+// isCTEName 判断表名是否引用当前作用域内的 CTE
+func (v *ExtractVisitor) isCTEName(name string) bool {
+	return lo.Contains(v.cteNames, strings.ToLower(name))
+}
+
+// appendSetOprType 添加集合操作符 (UNION, UNION ALL, EXCEPT, INTERSECT ...) 到 SQL 字符串
+func (v *ExtractVisitor) appendSetOprType(tp *ast.SetOprType) {
+	if tp == nil {
+		return
+	}
+
+	v.builder.WriteString(" ")
+	v.builder.WriteString(tp.String())
+	v.builder.WriteString(" ")
+}
+
+// appendOrderBy 添加 ORDER BY 子句到 SQL 字符串
+func (v *ExtractVisitor) appendOrderBy(node *ast.OrderByClause) {
+	v.builder.WriteString(" ORDER BY ")
+	v.clause = models.ClauseOrderBy
+	for idx := range node.Items {
+		if idx > 0 {
+			v.builder.WriteString(", ")
+		}
+
+		node.Items[idx].Accept(v)
+	}
+}
+
+// INSERT / REPLACE 语句
 func (v *ExtractVisitor) handleInsertStmt(node *ast.InsertStmt) {
 	if v.opType == models.SQLOperationUnknown {
-		v.opType = models.SQLOperationInsert
+		if node.IsReplace {
+			v.opType = models.SQLOperationReplace
+		} else {
+			v.opType = models.SQLOperationInsert
+		}
+	}
+	defer v.enterStmtScope()()
+
+	if node.IsReplace {
+		v.builder.WriteString("REPLACE ")
+	} else {
+		v.builder.WriteString("INSERT ")
+	}
+
+	// LOW_PRIORITY / HIGH_PRIORITY / DELAYED
+	if node.Priority != mysql.NoPriority {
+		v.builder.WriteString(mysql.Priority2Str[node.Priority])
+		v.builder.WriteString(" ")
 	}
 
-	v.builder.WriteString("INSERT ")
 	// INSERT IGNORE
 	if node.IgnoreErr {
 		v.builder.WriteString("IGNORE ")
 	}
 	v.builder.WriteString("INTO ")
+	v.clause = models.ClauseInto
 
 	// TABLE
+	var (
+		colStart = len(v.columns)
+		tables   []*models.TableInfo
+	)
 	if node.Table.TableRefs != nil {
+		start := len(v.tableInfos)
+		v.access = models.AccessWrite
 		node.Table.TableRefs.Accept(v) // call handleTableSource()
+		v.access = models.AccessRead
+		tables = v.resolveTables(v.tableInfos[start:])
 	}
+	defer v.resolveColumns(colStart, tables)
 
+	// PARTITION (p0, p1)
+	if len(node.PartitionNames) > 0 {
+		v.builder.WriteString(" PARTITION (")
+		for idx := range node.PartitionNames {
+			if idx > 0 {
+				v.builder.WriteString(", ")
+			}
+
+			v.builder.WriteString(node.PartitionNames[idx].O)
+		}
+		v.builder.WriteString(")")
+	}
+
+	// INSERT INTO t SET a = 1, b = 2
+	if node.Setlist && len(node.Lists) == 1 && len(node.Lists[0]) == len(node.Columns) {
+		v.builder.WriteString(" SET ")
+		v.clause = models.ClauseSet
+		for idx := range node.Columns {
+			if idx > 0 {
+				v.builder.WriteString(", ")
+			}
+
+			v.handleAssignment(&ast.Assignment{Column: node.Columns[idx], Expr: node.Lists[0][idx]})
+		}
+	} else {
+		v.appendInsertValues(node)
+	}
+
+	// ON DUPLICATE KEY UPDATE
+	if node.OnDuplicate != nil {
+		v.builder.WriteString(" ON DUPLICATE KEY UPDATE ")
+		v.clause = models.ClauseOnDuplicate
+
+		for idx := range node.OnDuplicate {
+			if idx > 0 {
+				v.builder.WriteString(", ")
+			}
+
+			node.OnDuplicate[idx].Accept(v)
+		}
+	}
+}
+
+// appendInsertValues 添加 INSERT 语句的列及 VALUES / SELECT 部分到 SQL 字符串
+func (v *ExtractVisitor) appendInsertValues(node *ast.InsertStmt) {
 	// COLUMNS
+	colStart := len(v.columns)
 	if len(node.Columns) > 0 {
 		v.builder.WriteString(" (")
 		for idx, col := range node.Columns {
@@ -396,6 +1099,7 @@ func (v *ExtractVisitor) handleInsertStmt(node *ast.InsertStmt) {
 			}
 
 			v.builder.WriteString(col.Name.O)
+			v.appendColumn(col, models.AccessWrite)
 		}
 		v.builder.WriteString(")")
 	}
@@ -403,6 +1107,7 @@ func (v *ExtractVisitor) handleInsertStmt(node *ast.InsertStmt) {
 	// VALUES
 	if node.Lists != nil {
 		v.builder.WriteString(" VALUES ")
+		v.clause = models.ClauseValues
 		for idx, list := range node.Lists {
 			if idx > 0 {
 				v.builder.WriteString(", ")
@@ -414,7 +1119,13 @@ func (v *ExtractVisitor) handleInsertStmt(node *ast.InsertStmt) {
 					v.builder.WriteString(", ")
 				}
 
+				paramStart := len(v.params)
 				item.Accept(v)
+
+				// 值绑定到列列表中对应位置的列
+				if len(list) == len(node.Columns) && isINListValue(item) {
+					v.bindParams(paramStart, colStart+jdx)
+				}
 			}
 			v.builder.WriteString(")")
 		}
@@ -422,19 +1133,6 @@ func (v *ExtractVisitor) handleInsertStmt(node *ast.InsertStmt) {
 		v.builder.WriteString(" ")
 		node.Select.Accept(v)
 	}
-
-	// ON DUPLICATE KEY UPDATE
-	if node.OnDuplicate != nil {
-		v.builder.WriteString(" ON DUPLICATE KEY UPDATE ")
-
-		for idx := range node.OnDuplicate {
-			if idx > 0 {
-				v.builder.WriteString(", ")
-			}
-
-			node.OnDuplicate[idx].Accept(v)
-		}
-	}
 }
 
 // UPDATE
@@ -442,15 +1140,33 @@ func (v *ExtractVisitor) handleUpdateStmt(node *ast.UpdateStmt) {
 	if v.opType == models.SQLOperationUnknown {
 		v.opType = models.SQLOperationUpdate
 	}
+	defer v.enterStmtScope()()
+
+	// WITH 子句
+	if node.With != nil {
+		defer v.popCTENames(len(v.cteNames))
+		v.handleWithClause(node.With)
+	}
 
 	v.builder.WriteString("UPDATE ")
+	v.clause = models.ClauseFrom
 
+	var (
+		colStart = len(v.columns)
+		tables   []*models.TableInfo
+	)
 	if node.TableRefs != nil && node.TableRefs.TableRefs != nil {
+		start := len(v.tableInfos)
+		v.writeTargets = updateTargets(node.List)
 		node.TableRefs.TableRefs.Accept(v) // call handleTableSource()
+		v.writeTargets = nil
+		tables = v.resolveTables(v.tableInfos[start:])
 	}
+	defer v.resolveColumns(colStart, tables)
 
 	// SET
 	v.builder.WriteString(" SET ")
+	v.clause = models.ClauseSet
 	for idx := range node.List {
 		if idx > 0 {
 			v.builder.WriteString(", ")
@@ -462,12 +1178,14 @@ func (v *ExtractVisitor) handleUpdateStmt(node *ast.UpdateStmt) {
 	// WHERE
 	if node.Where != nil {
 		v.builder.WriteString(" WHERE ")
+		v.clause = models.ClauseWhere
 		node.Where.Accept(v)
 	}
 
 	// ORDER BY
 	if node.Order != nil {
 		v.builder.WriteString(" ORDER BY ")
+		v.clause = models.ClauseOrderBy
 		for idx := range node.Order.Items {
 			if idx > 0 {
 				v.builder.WriteString(", ")
@@ -488,35 +1206,60 @@ func (v *ExtractVisitor) handleDeleteStmt(node *ast.DeleteStmt) {
 	if v.opType == models.SQLOperationUnknown {
 		v.opType = models.SQLOperationDelete
 	}
+	defer v.enterStmtScope()()
+
+	// WITH 子句
+	if node.With != nil {
+		defer v.popCTENames(len(v.cteNames))
+		v.handleWithClause(node.With)
+	}
 
 	v.builder.WriteString("DELETE ")
+	v.clause = models.ClauseFrom
+
+	// 单表 DELETE 修改 FROM 中的表, 多表 DELETE 修改 DELETE 与 FROM 之间列出的表
+	v.writeTargets = []string{allWriteTargets}
 
+	targetStart, aliasStart := len(v.tableInfos), len(v.aliases)
 	if node.Tables != nil {
+		v.access = models.AccessWrite
+		v.writeTargets = make([]string, 0, len(node.Tables.Tables))
 		for idx := range node.Tables.Tables {
 			if idx > 0 {
 				v.builder.WriteString(", ")
 			}
 
-			node.Tables.Tables[idx].Accept(v)
+			target := node.Tables.Tables[idx]
+			target.Accept(v)
+			v.writeTargets = append(v.writeTargets, writeTargetKey(target.Schema.L, target.Name.L))
 		}
 		v.builder.WriteString(" ")
+		v.access = models.AccessRead
 	}
 	v.builder.WriteString("FROM ")
 
+	targetEnd, colStart := len(v.tableInfos), len(v.columns)
+
 	// TABLE
 	if node.TableRefs != nil && node.TableRefs.TableRefs != nil { // ast.Join
 		node.TableRefs.TableRefs.Accept(v)
 	}
+	v.writeTargets = nil
+	defer v.resolveColumns(colStart, v.resolveTables(v.tableInfos[targetEnd:]))
+
+	v.dropAliasTargets(targetStart, targetEnd, v.aliases[aliasStart:])
 
 	// WHERE
 	if node.Where != nil {
 		v.builder.WriteString(" WHERE ")
+		v.clause = models.ClauseWhere
 		node.Where.Accept(v)
 	}
 
 	// ORDER BY
 	if node.Order != nil {
 		v.builder.WriteString(" ORDER BY ")
+		v.clause = models.ClauseOrderBy
 		for idx := range node.Order.Items {
 			if idx > 0 {
 				v.builder.WriteString(", ")
@@ -532,6 +1275,55 @@ func (v *ExtractVisitor) handleDeleteStmt(node *ast.DeleteStmt) {
 	}
 }
 
+// allWriteTargets 表示 UPDATE / DELETE 的全部表均被修改
+const allWriteTargets = "*"
+
+// updateTargets 返回 UPDATE 中被赋值的表, 见 writeTargetKey
+//
+// 未限定表名的列无法确定所属表, 此时视为全部表均被修改, 单表 UPDATE 即为该表
+func updateTargets(list []*ast.Assignment) []string {
+	targets := make([]string, 0, len(list))
+	for _, assignment := range list {
+		if assignment.Column.Table.L == "" {
+			return []string{allWriteTargets}
+		}
+
+		targets = append(targets, writeTargetKey(assignment.Column.Schema.L, assignment.Column.Table.L))
+	}
+
+	return targets
+}
+
+// writeTargetKey 返回被修改的表的键, 即小写的 schema.table, 未限定库名时为表名或别名
+func writeTargetKey(schema, name string) string {
+	if schema == "" {
+		return strings.ToLower(name)
+	}
+
+	return strings.ToLower(schema) + "." + strings.ToLower(name)
+}
+
+// isWriteTarget 判断 UPDATE / DELETE 表引用中的表是否被修改, 有别名时 schema 为空, name 为别名
+//
+// 限定库名的目标仅匹配同一库中的表, 未限定库名的目标匹配同名的表
+func (v *ExtractVisitor) isWriteTarget(schema, name string) bool {
+	return lo.Contains(v.writeTargets, allWriteTargets) ||
+		lo.Contains(v.writeTargets, writeTargetKey(schema, name)) ||
+		schema != "" && lo.Contains(v.writeTargets, writeTargetKey("", name))
+}
+
+// dropAliasTargets 移除多表 DELETE 中引用别名的目标表, 其位于 tableInfos[start:end],
+// e.g. DELETE o FROM orders AS o 中的 o 不是物理表
+func (v *ExtractVisitor) dropAliasTargets(start, end int, aliases []models.Alias) {
+	targets := lo.Reject(v.tableInfos[start:end], func(t *models.TableInfo, _ int) bool {
+		return t.Schema() == "" && lo.ContainsBy(aliases, func(alias models.Alias) bool {
+			return strings.EqualFold(alias.Name, t.TableName())
+		})
+	})
+
+	v.tableInfos = slices.Replace(v.tableInfos, start, end, targets...)
+}
+
 // handleExplainStmt 处理 EXPLAIN 语句
 func (v *ExtractVisitor) handleExplainStmt(node *ast.ExplainStmt) {
 	if v.opType == models.SQLOperationUnknown {
@@ -550,17 +1342,35 @@ func (v *ExtractVisitor) handleExplainStmt(node *ast.ExplainStmt) {
 
 	// 递归处理被解释的语句
 	if node.Stmt != nil {
+		start := len(v.tableInfos)
 		node.Stmt.Accept(v)
+
+		// 仅 EXPLAIN ANALYZE 会执行语句, 否则只读取元数据
+		if !node.Analyze {
+			for _, ti := range v.tableInfos[start:] {
+				ti.SetAccess(models.AccessMetadata)
+			}
+		}
 	}
 }
 
 // handleTableSource 处理表源
 func (v *ExtractVisitor) handleTableSource(node *ast.TableSource) {
+	start := len(v.tableInfos)
+
 	switch src := node.Source.(type) {
 	case *ast.TableName:
 		src.Accept(v)
 
-	case *ast.SelectStmt:
+		schema, name := src.Schema.O, src.Name.O
+		if node.AsName.O != "" {
+			schema, name = "", node.AsName.O
+		}
+		if ti := v.tableInfos[start]; !ti.IsCTE() && v.isWriteTarget(schema, name) {
+			ti.SetAccess(models.AccessWrite)
+		}
+
+	case *ast.SelectStmt, *ast.SetOprStmt:
 		v.builder.WriteString("(")
 		src.Accept(v)
 		v.builder.WriteString(")")
@@ -569,49 +1379,121 @@ func (v *ExtractVisitor) handleTableSource(node *ast.TableSource) {
 		src.Accept(v)
 
 	default:
-		v.logError(fmt.Sprintf("TableSource.Source type: %T", src))
+		v.reportUnhandled(src, "unhandled table source")
 		node.Source.Accept(v)
 	}
 
 	if node.AsName.O != "" {
 		v.builder.WriteString(" AS ")
 		v.builder.WriteString(node.AsName.O)
+		v.appendAlias(node, v.tableInfos[start:])
+	}
+
+	// 索引提示位于别名之后, e.g. t AS a USE INDEX (idx_a)
+	if src, ok := node.Source.(*ast.TableName); ok {
+		for _, hint := range src.IndexHints {
+			v.builder.WriteString(" ")
+			v.restore(hint)
+		}
+		if src.AsOf != nil {
+			v.reportUnhandled(src.AsOf, "unhandled as of timestamp")
+		}
+		if src.TableSample != nil {
+			v.reportUnhandled(src.TableSample, "unhandled table sample")
+		}
+	}
+}
+
+// appendAlias 记录表源的别名, tableInfos 为处理该表源时添加的表信息
+func (v *ExtractVisitor) appendAlias(node *ast.TableSource, tableInfos []*models.TableInfo) {
+	alias := models.Alias{
+		Name:   node.AsName.O,
+		Kind:   models.AliasDerived,
+		Tables: v.resolveTables(tableInfos),
+	}
+
+	if _, ok := node.Source.(*ast.TableName); ok && len(tableInfos) > 0 {
+		alias.Kind = lo.Ternary(tableInfos[0].IsCTE(), models.AliasCTE, models.AliasTable)
 	}
+
+	v.aliases = append(v.aliases, alias)
+}
+
+// resolveTables 返回表信息对应的物理表, CTE 引用替换为该 CTE 读取的表
+func (v *ExtractVisitor) resolveTables(tableInfos []*models.TableInfo) []*models.TableInfo {
+	tables := make([]*models.TableInfo, 0, len(tableInfos))
+	for _, ti := range tableInfos {
+		if !ti.IsCTE() {
+			tables = append(tables, ti)
+			continue
+		}
+
+		// 最近声明的同名 CTE, 递归 CTE 在自身定义中的引用尚未记录
+		for idx := len(v.aliases) - 1; idx >= 0; idx-- {
+			if alias := v.aliases[idx]; alias.Kind == models.AliasCTE && strings.EqualFold(alias.Name, ti.TableName()) {
+				tables = append(tables, alias.Tables...)
+				break
+			}
+		}
+	}
+
+	return lo.UniqBy(tables, tableKey)
 }
 
 func (v *ExtractVisitor) handleTableName(node *ast.TableName) {
-	v.tableInfos = append(v.tableInfos, models.NewTableInfo())
+	// CTE 引用: 不做分库分表模板化
+	if node.Schema.O == "" && v.isCTEName(node.Name.O) {
+		v.builder.WriteString(node.Name.O)
+
+		ti := models.NewTableInfo("", node.Name.O, "", node.Name.O)
+		ti.SetCTE(true)
+		ti.SetAccess(v.access)
+		v.tableInfos = append(v.tableInfos, ti)
+		return
+	}
+
+	ti := models.NewTableInfo()
+	ti.SetAccess(v.access)
+	v.tableInfos = append(v.tableInfos, ti)
 
 	if node.Schema.O != "" {
-		TemplizedSchema := v.templateTable(node.Schema.O)
+		TemplizedSchema, indexes := templateShardName(v.conf().schemaShard, node.Schema.O)
 		v.builder.WriteString(TemplizedSchema)
 		v.builder.WriteString(".")
 
-		v.tableInfos[len(v.tableInfos)-1].SetSchema(node.Schema.O)
-		v.tableInfos[len(v.tableInfos)-1].SetTemplatizedSchema(TemplizedSchema)
+		ti.SetSchema(node.Schema.O)
+		ti.SetTemplatizedSchema(TemplizedSchema)
+		ti.SetSchemaShardIndexes(indexes)
 	}
 
-	TemplatizedTable := v.templateTable(node.Name.O)
+	TemplatizedTable, indexes := templateShardName(v.conf().tableShard, node.Name.O)
 	v.builder.WriteString(TemplatizedTable)
-	v.tableInfos[len(v.tableInfos)-1].SetTableName(node.Name.O)
-	v.tableInfos[len(v.tableInfos)-1].SetTemplatizedTableName(TemplatizedTable)
+	ti.SetTableName(node.Name.O)
+	ti.SetTemplatizedTableName(TemplatizedTable)
+	ti.SetTableShardIndexes(indexes)
+
+	// 分区选择, e.g. t PARTITION (p0, p1)
+	for idx, partition := range node.PartitionNames {
+		v.builder.WriteString(lo.Ternary(idx == 0, " PARTITION (", ", "))
+		v.builder.WriteString(partition.O)
+	}
+	if len(node.PartitionNames) > 0 {
+		v.builder.WriteString(")")
+	}
 }
 
-// templateTable 模板化 table
-//
-// - 如果 table 中包含 _ 且最后一个部分是数字，则认为是分库分表的表名，将最后一个部分替换为若干个 x
-// - 如果 table 中不包含 _ 或最后一个部分不是数字，则返回原值
-func (v *ExtractVisitor) templateTable(table string) string {
-	if table == "" || !strings.Contains(table, "_") {
-		return table
+// templateShardName 按 namer 模板化分库分表名称, 返回模板化后的名称及分片序号, 非分片名称时返回原值
+func templateShardName(namer ShardNamer, name string) (string, []string) {
+	if name == "" {
+		return name, nil
 	}
 
-	parts := strings.Split(table, "_")
-	if _, err := strconv.Atoi(parts[len(parts)-1]); err == nil {
-		return strings.Join(parts[0:len(parts)-1], "_") + "_" + tablePlaceholder
+	template, indexes, ok := namer.Template(name)
+	if !ok {
+		return name, nil
 	}
 
-	return table
+	return template, indexes
 }
 
 func (v *ExtractVisitor) handleJoin(node *ast.Join) {
@@ -625,7 +1507,7 @@ func (v *ExtractVisitor) handleJoin(node *ast.Join) {
 			left.Accept(v)
 
 		default:
-			v.logError(fmt.Sprintf("Join.Left type: %T", left))
+			v.reportUnhandled(left, "unhandled join left")
 			left.Accept(v)
 		}
 	}
@@ -633,10 +1515,16 @@ func (v *ExtractVisitor) handleJoin(node *ast.Join) {
 	// 只有存在右节点时，才添加 JOIN 关键字
 	if node.Right != nil {
 		// JOIN Type
-		if joinStr, ok := joinTypeMap[node.Tp]; ok {
-			v.builder.WriteString(joinStr)
-		} else {
+		if node.NaturalJoin {
+			v.builder.WriteString(" NATURAL")
+		}
+		switch joinStr, ok := joinTypeMap[node.Tp]; {
+		case node.StraightJoin:
+			v.builder.WriteString(" STRAIGHT_JOIN ")
+		case node.NaturalJoin && node.Tp == ast.CrossJoin, !ok:
 			v.builder.WriteString(" JOIN ")
+		default:
+			v.builder.WriteString(joinStr)
 		}
 
 		switch right := node.Right.(type) {
@@ -644,7 +1532,7 @@ func (v *ExtractVisitor) handleJoin(node *ast.Join) {
 			right.Accept(v)
 
 		default:
-			v.logError(fmt.Sprintf("Join.Right type: %T", right))
+			v.reportUnhandled(right, "unhandled join right")
 			node.Right.Accept(v)
 		}
 
@@ -653,31 +1541,75 @@ func (v *ExtractVisitor) handleJoin(node *ast.Join) {
 			v.builder.WriteString(" ON ")
 			node.On.Accept(v)
 		}
+
+		// USING (column, ...)
+		if len(node.Using) > 0 {
+			v.appendJoinUsing(node.Using)
+		}
+	}
+}
+
+// appendJoinUsing 添加 JOIN 的 USING 列, 列记录在 ON 子句中
+func (v *ExtractVisitor) appendJoinUsing(using []*ast.ColumnName) {
+	defer v.restoreClause(v.clause)
+	v.clause = models.ClauseOn
+
+	v.builder.WriteString(" USING (")
+	for idx, name := range using {
+		if idx > 0 {
+			v.builder.WriteString(", ")
+		}
+
+		v.appendColumnName(name)
+		v.appendColumn(name, models.AccessRead)
 	}
+	v.builder.WriteString(")")
 }
 
 func (v *ExtractVisitor) handlePatternLikeOrIlikeExpr(node *ast.PatternLikeOrIlikeExpr) {
+	col, paramStart := len(v.columns), len(v.params)
 	node.Expr.Accept(v)
 	if node.Not {
 		v.builder.WriteString(" NOT")
 	}
-	v.builder.WriteString(" LIKE ")
+	if node.IsLike {
+		v.builder.WriteString(" LIKE ")
+	} else {
+		v.builder.WriteString(" ILIKE ")
+	}
 
 	// For LIKE patterns, all ValueExpr types are parameterized with '?'
 	// to maintain strict templatization.
 	if pattern, ok := node.Pattern.(*test_driver.ValueExpr); ok {
-		v.builder.WriteString("?")
-		v.params = append(v.params, pattern.GetValue())
+		v.appendPlaceholder("?")
+		v.appendParam(pattern.GetValue(), pattern)
 	} else {
 		node.Pattern.Accept(v)
 	}
 
-	// FIXME 处理 LIKE 模式中的转义字符
-	// if node.Escape != 0 {
-	// 	v.builder.WriteString(" ESCAPE ")
-	// 	v.builder.WriteString("?")
-	// 	v.params = append(v.params, node.Escape)
-	// }
+	// 转义字符决定模式的含义, 非默认的 `\` 时保留在模板中
+	if node.Escape != '\\' {
+		v.builder.WriteString(" ESCAPE ")
+		v.builder.WriteString(literal.Quote(string(node.Escape)))
+	}
+
+	if _, ok := node.Expr.(*ast.ColumnNameExpr); ok && isINListValue(node.Pattern) {
+		v.appendPredicate(col, likePredicateOp(node), paramStart)
+	}
+}
+
+// likePredicateOp 返回 LIKE / ILIKE 表达式的谓词运算符
+func likePredicateOp(node *ast.PatternLikeOrIlikeExpr) models.PredicateOp {
+	switch {
+	case node.IsLike && node.Not:
+		return models.PredicateNotLike
+	case node.IsLike:
+		return models.PredicateLike
+	case node.Not:
+		return models.PredicateNotILike
+	default:
+		return models.PredicateILike
+	}
 }
 
 // handlePatternRegexpExpr 处理 REGEXP 模式
@@ -690,29 +1622,24 @@ func (v *ExtractVisitor) handlePatternRegexpExpr(node *ast.PatternRegexpExpr) {
 
 	// For REGEXP patterns
 	if pattern, ok := node.Pattern.(*test_driver.ValueExpr); ok {
-		v.builder.WriteString("?")
-		v.params = append(v.params, pattern.GetValue())
+		v.appendPlaceholder("?")
+		v.appendParam(pattern.GetValue(), pattern)
 	} else {
 		node.Pattern.Accept(v)
 	}
 }
 
 func (v *ExtractVisitor) handlePatternInExpr(node *ast.PatternInExpr) {
+	col := len(v.columns)
 	node.Expr.Accept(v)
 	if node.Not {
 		v.builder.WriteString(" NOT")
 	}
 	v.builder.WriteString(" IN (")
 
+	paramStart := len(v.params)
 	if node.List != nil {
-		v.builder.WriteString("?")
-
-		for idx := range node.List {
-			// 如果是 ValueExpr，保存参数值
-			if valExpr, ok := node.List[idx].(*test_driver.ValueExpr); ok {
-				v.params = append(v.params, valExpr.GetValue())
-			}
-		}
+		v.appendINList(node.List)
 	}
 
 	if node.Sel != nil {
@@ -720,15 +1647,233 @@ func (v *ExtractVisitor) handlePatternInExpr(node *ast.PatternInExpr) {
 	}
 
 	v.builder.WriteString(")")
+
+	// 仅由值组成的 IN 列表
+	_, isColumn := node.Expr.(*ast.ColumnNameExpr)
+	if isColumn && node.Sel == nil && len(node.List) > 0 && lo.EveryBy(node.List, isINListValue) {
+		v.appendPredicate(col, lo.Ternary(node.Not, models.PredicateNotIn, models.PredicateIn), paramStart)
+	}
+}
+
+// appendINList 添加 IN 列表到 SQL 字符串
+//
+// 除 INListExact 外, 列表中的字面值 (及仅由字面值组成的行) 合并为一个占位符, 输出在第一个字面值的位置,
+// 其余项 (占位符、列、函数等) 按原顺序逐项输出, e.g. IN (a.id, 5, NOW(), ?, 7) -> IN (a.id, ?, NOW(), ?)
+func (v *ExtractVisitor) appendINList(list []ast.ExprNode) {
+	mode := v.conf().inListMode
+	if mode == INListExact {
+		for idx := range list {
+			if idx > 0 {
+				v.builder.WriteString(", ")
+			}
+			list[idx].Accept(v)
+		}
+		return
+	}
+
+	literals := lo.CountBy(list, isINListLiteral)
+	parts, collapsed := 0, false
+	for _, item := range list {
+		isLiteral := isINListLiteral(item)
+		if isLiteral && collapsed {
+			continue
+		}
+
+		if parts > 0 {
+			v.builder.WriteString(", ")
+		}
+		parts++
+
+		if !isLiteral {
+			item.Accept(v)
+			continue
+		}
+
+		if mode == INListBucket {
+			v.appendPlaceholder(inListBucket(literals))
+		} else {
+			v.appendPlaceholder("?")
+		}
+
+		// 所有字面值的参数在合并后的占位符处按原顺序添加
+		for _, val := range list {
+			if isINListLiteral(val) {
+				v.appendINListParams(val)
+			}
+		}
+		collapsed = true
+	}
+}
+
+// isINListValue 判断 IN 列表项是否为值: 字面值、占位符或仅由它们组成的行
+func isINListValue(expr ast.ExprNode) bool {
+	switch item := expr.(type) {
+	case *test_driver.ValueExpr, *test_driver.ParamMarkerExpr:
+		return true
+
+	case *ast.UnaryOperationExpr:
+		return isNegativeLiteral(item)
+
+	case *ast.RowExpr:
+		return lo.EveryBy(item.Values, isINListValue)
+
+	default:
+		return false
+	}
+}
+
+// isINListLiteral 判断 IN 列表项是否为可合并的字面值或仅由字面值组成的行,
+// e.g. (a, b) IN ((1, 2), (3, 4)) 与标量列表一致合并
+func isINListLiteral(expr ast.ExprNode) bool {
+	switch item := expr.(type) {
+	case *test_driver.ValueExpr:
+		return true
+
+	case *ast.RowExpr:
+		return lo.EveryBy(item.Values, isINListLiteral)
+
+	default:
+		return false
+	}
+}
+
+// appendINListParams 添加可合并的 IN 列表项的参数值
+func (v *ExtractVisitor) appendINListParams(expr ast.ExprNode) {
+	switch item := expr.(type) {
+	case *test_driver.ValueExpr:
+		v.appendParam(item.GetValue(), item)
+
+	case *ast.RowExpr:
+		for _, val := range item.Values {
+			v.appendINListParams(val)
+		}
+	}
+}
+
+// isNegativeLiteral 判断表达式是否为负号与字面值组成的负数, e.g. -5,
+// 负号保留在模板中 (minus ?), 参数为其绝对值, 因此不与其他字面值合并
+func isNegativeLiteral(node *ast.UnaryOperationExpr) bool {
+	_, ok := node.V.(*test_driver.ValueExpr)
+	return ok && node.Op == opcode.Minus
+}
+
+// inListBucket 返回 IN 列表按数量级分桶后的占位符:
+// 1 -> ?, 2~10 -> ?+, 11~100 -> ?++, 101~1000 -> ?+++ ...
+func inListBucket(size int) string {
+	bucket := "?"
+	for limit := 1; size > limit; limit *= 10 {
+		bucket += "+"
+	}
+
+	return bucket
 }
 
 func (v *ExtractVisitor) handleBinaryOperationExpr(node *ast.BinaryOperationExpr) {
+	if op, ok := logicOpMap[node.Op]; ok {
+		defer v.enterLogicOp(op)()
+	}
+
+	// 列与值的比较, 值在左侧时交换比较方向, e.g. ? < a 即 a > ?
+	op, isCompare := predicateOpMap[node.Op]
+	_, leftIsColumn := node.L.(*ast.ColumnNameExpr)
+	_, rightIsColumn := node.R.(*ast.ColumnNameExpr)
+	isPredicate := isCompare && (leftIsColumn && isINListValue(node.R) || rightIsColumn && isINListValue(node.L))
+	if isPredicate && rightIsColumn && !leftIsColumn {
+		op = lo.ValueOr(reversedPredicateOpMap, op, op)
+	}
+	col, paramStart := len(v.columns), len(v.params)
+
 	node.L.Accept(v)
-	fmt.Fprintf(v.builder, " %s ", node.Op.String())
+	v.builder.WriteString(" ")
+	v.builder.WriteString(v.operator(node.Op))
+	v.builder.WriteString(" ")
+	if isPredicate && !leftIsColumn {
+		col = len(v.columns)
+	}
 	node.R.Accept(v)
+
+	if isPredicate {
+		v.appendPredicate(col, op, paramStart)
+	}
+}
+
+// 比较运算符对应的谓词运算符
+var predicateOpMap = map[opcode.Op]models.PredicateOp{
+	opcode.EQ:     models.PredicateEQ,
+	opcode.NE:     models.PredicateNE,
+	opcode.LT:     models.PredicateLT,
+	opcode.LE:     models.PredicateLE,
+	opcode.GT:     models.PredicateGT,
+	opcode.GE:     models.PredicateGE,
+	opcode.NullEQ: models.PredicateNullEQ,
+}
+
+// 交换左右两侧后的谓词运算符
+var reversedPredicateOpMap = map[models.PredicateOp]models.PredicateOp{
+	models.PredicateLT: models.PredicateGT,
+	models.PredicateLE: models.PredicateGE,
+	models.PredicateGT: models.PredicateLT,
+	models.PredicateGE: models.PredicateLE,
+}
+
+// 逻辑运算符, 用于记录谓词所在的逻辑上下文
+var logicOpMap = map[opcode.Op]models.LogicOp{
+	opcode.LogicAnd: models.LogicAnd,
+	opcode.LogicOr:  models.LogicOr,
+	opcode.LogicXor: models.LogicXor,
+	opcode.Not:      models.LogicNot,
+	opcode.Not2:     models.LogicNot,
+}
+
+// enterLogicOp 进入逻辑运算符, 返回恢复外层逻辑上下文的函数
+//
+// 连续的相同运算符只记录一次, e.g. a = ? AND b = ? AND c = ? 中各谓词的上下文均为 [AND]
+func (v *ExtractVisitor) enterLogicOp(op models.LogicOp) func() {
+	n := len(v.logicOps)
+	if n > 0 && v.logicOps[n-1] == op && op != models.LogicNot {
+		return func() {}
+	}
+	v.logicOps = append(v.logicOps, op)
+
+	return func() { v.logicOps = v.logicOps[:n] }
+}
+
+// appendPredicate 记录列 columns[col] 与值的比较, 值的参数为 params[paramStart:]
+func (v *ExtractVisitor) appendPredicate(col int, op models.PredicateOp, paramStart int) {
+	if col >= len(v.columns) { // 列未被记录
+		return
+	}
+
+	var indexes []int
+	for idx := paramStart; idx < len(v.params); idx++ {
+		indexes = append(indexes, idx)
+	}
+
+	v.bindParams(paramStart, col)
+	v.predicates = append(v.predicates, predicateRef{
+		Predicate: models.Predicate{
+			Operator:     op,
+			ParamIndexes: indexes,
+			Context:      slices.Clone(v.logicOps),
+		},
+		column: col,
+	})
+}
+
+// operator 返回运算符的输出形式:
+// RenderModeSQL 下为合法的 MySQL 运算符 (e.g. =, AND, <=>), 否则为 opcode 名称 (e.g. eq, and, nulleq)
+func (v *ExtractVisitor) operator(op opcode.Op) string {
+	if v.conf().renderMode == RenderModeSQL {
+		if str, ok := sqlOperatorMap[op]; ok {
+			return str
+		}
+	}
+
+	return op.String()
 }
 
 func (v *ExtractVisitor) handleBetweenExpr(node *ast.BetweenExpr) {
+	col := len(v.columns)
 	node.Expr.Accept(v)
 
 	if node.Not {
@@ -737,59 +1882,114 @@ func (v *ExtractVisitor) handleBetweenExpr(node *ast.BetweenExpr) {
 		v.builder.WriteString(" BETWEEN ")
 	}
 
+	paramStart := len(v.params)
 	node.Left.Accept(v)
 	v.builder.WriteString(" AND ")
 	node.Right.Accept(v)
+
+	if _, ok := node.Expr.(*ast.ColumnNameExpr); ok && isINListValue(node.Left) && isINListValue(node.Right) {
+		v.appendPredicate(col, lo.Ternary(node.Not, models.PredicateNotBetween, models.PredicateBetween), paramStart)
+	}
 }
 
 func (v *ExtractVisitor) handleValueExpr(node *test_driver.ValueExpr) {
-	if v.inAggrFunc { // 在聚合函数中，直接输出值
-		switch val := node.GetValue().(type) {
-		case int64, uint64:
-			fmt.Fprintf(v.builder, "%d", val)
-
-		case float64:
-			fmt.Fprintf(v.builder, "%f", val)
-
-		case string:
-			fmt.Fprintf(v.builder, "'%s'", val)
-
-		case *test_driver.MyDecimal:
-			v.builder.WriteString(val.String())
-
-		default:
-			fmt.Printf("ValueExpr type: %T\n", node.GetValue())
-			fmt.Fprintf(v.builder, "%v", val)
+	if v.keepLiteral() { // 按配置直接输出值, 默认仅聚合函数中的值
+		lit, err := literal.Format(node.GetValue())
+		if err != nil {
+			v.reportUnhandled(node, err.Error())
+			lit = "?"
 		}
+		v.builder.WriteString(lit)
+		v.markKeptLiteral(node)
 	} else {
 		// param -> ?
-		v.builder.WriteString("?")
-		v.params = append(v.params, node.GetValue())
+		v.appendPlaceholder("?")
+		v.appendParam(node.GetValue(), node)
 	}
 }
 
 func (v *ExtractVisitor) handleColumnNameExpr(node *ast.ColumnNameExpr) {
+	v.appendColumnName(node.Name)
+	v.appendColumn(node.Name, models.AccessRead)
+}
+
+// appendColumnName 添加 (限定的) 列名到 SQL 字符串
+func (v *ExtractVisitor) appendColumnName(name *ast.ColumnName) {
 	var schema, table string
-	if node.Name.Schema.O != "" {
-		schema = node.Name.Schema.O + "."
+	if name.Schema.O != "" {
+		schema = name.Schema.O + "."
+	}
+
+	if name.Table.O != "" {
+		table = name.Table.O + "."
+	}
+
+	v.builder.WriteString(schema + table + name.Name.O)
+}
+
+// appendColumn 记录当前子句中引用的列
+func (v *ExtractVisitor) appendColumn(name *ast.ColumnName, access models.AccessMode) {
+	v.columns = append(v.columns, columnRef{Column: models.Column{
+		Schema: name.Schema.O,
+		Table:  name.Table.O,
+		Name:   name.Name.O,
+		Clause: v.clause,
+		Access: access,
+	}})
+}
+
+// resolveColumns 将 columns[start:] 中尚未归属的未限定列归属于当前查询块的表 tables
+func (v *ExtractVisitor) resolveColumns(start int, tables []*models.TableInfo) {
+	for idx := range v.columns[start:] {
+		col := &v.columns[start+idx]
+		if col.Table == "" && !col.resolved {
+			col.Tables, col.resolved = tables, true
+		}
+	}
+}
+
+// markFieldAliases 标记 columns[start:] 中引用 SELECT 列表别名的列,
+// e.g. SELECT COUNT(*) AS cnt FROM t ORDER BY cnt 中的 cnt 不是表的列
+func (v *ExtractVisitor) markFieldAliases(start int, fields *ast.FieldList) {
+	if fields == nil {
+		return
 	}
 
-	if node.Name.Table.O != "" {
-		table = node.Name.Table.O + "."
+	isFieldAlias := func(col *columnRef) bool {
+		if col.Table != "" || col.resolved {
+			return false
+		}
+
+		switch col.Clause {
+		case models.ClauseGroupBy, models.ClauseHaving, models.ClauseOrderBy:
+			return lo.ContainsBy(fields.Fields, func(field *ast.SelectField) bool {
+				// SELECT a AS a 的别名即为该列
+				expr, isColumn := field.Expr.(*ast.ColumnNameExpr)
+				return strings.EqualFold(field.AsName.O, col.Name) &&
+					!(isColumn && strings.EqualFold(expr.Name.Name.O, col.Name))
+			})
+		default:
+			return false
+		}
 	}
 
-	v.builder.WriteString(schema + table + node.Name.Name.O)
+	for idx := range v.columns[start:] {
+		col := &v.columns[start+idx]
+		if isFieldAlias(col) {
+			col.fieldAlias, col.resolved = true, true
+		}
+	}
 }
 
 func (v *ExtractVisitor) handleByItem(node *ast.ByItem) {
 	node.Expr.Accept(v)
 
-	// 处理排序方向
+	// 处理排序方向, NullOrder 为 true 表示未显式指定方向, 显式的 ASC 保留在模板中
 	if node.Desc {
 		v.builder.WriteString(" DESC")
+	} else if !node.NullOrder {
+		v.builder.WriteString(" ASC")
 	}
-
-	// FIXME 处理 NULL 排序
 }
 
 func (v *ExtractVisitor) handleValuesExpr(node *ast.ValuesExpr) {
@@ -801,6 +2001,7 @@ func (v *ExtractVisitor) handleValuesExpr(node *ast.ValuesExpr) {
 
 func (v *ExtractVisitor) handleLimit(node *ast.Limit) {
 	v.builder.WriteString(" LIMIT ")
+	v.clause = models.ClauseLimit
 
 	if node.Offset != nil {
 		node.Offset.Accept(v)
@@ -817,14 +2018,26 @@ func (v *ExtractVisitor) handleSubqueryExpr(node *ast.SubqueryExpr) {
 }
 
 func (v *ExtractVisitor) handleOnCondition(node *ast.OnCondition) {
+	defer v.restoreClause(v.clause)
+	v.clause = models.ClauseOn
+
 	node.Expr.Accept(v)
 }
 
 // handleAssignment 处理赋值表达式
 func (v *ExtractVisitor) handleAssignment(node *ast.Assignment) {
-	v.handleColumnNameExpr(&ast.ColumnNameExpr{Name: node.Column}) // XXX
-	v.builder.WriteString(" eq ")
+	col, paramStart := len(v.columns), len(v.params)
+	v.appendColumnName(node.Column)
+	v.appendColumn(node.Column, models.AccessWrite)
+	v.builder.WriteString(" ")
+	v.builder.WriteString(v.operator(opcode.EQ))
+	v.builder.WriteString(" ")
 	node.Expr.Accept(v)
+
+	// 赋值的值绑定到被赋值的列
+	if isINListValue(node.Expr) {
+		v.bindParams(paramStart, col)
+	}
 }
 
 // handleExprNode 处理表达式节点
@@ -836,22 +2049,57 @@ func (v *ExtractVisitor) handleAggregateFuncExpr(node *ast.AggregateFuncExpr) {
 		v.builder.WriteString("DISTINCT ")
 	}
 
-	for idx := range node.Args {
+	// GROUP_CONCAT 的最后一个参数为分隔符
+	args := node.Args
+	isGroupConcat := strings.EqualFold(node.F, ast.AggFuncGroupConcat) && len(args) > 0
+	if isGroupConcat {
+		args = args[:len(args)-1]
+	}
+
+	for idx := range args {
 		if idx > 0 {
 			v.builder.WriteString(", ")
 		}
 
-		node.Args[idx].Accept(v)
+		args[idx].Accept(v)
+	}
+
+	if isGroupConcat {
+		v.appendGroupConcatOpts(node)
 	}
 	v.builder.WriteString(")")
 }
 
+// appendGroupConcatOpts 添加 GROUP_CONCAT 的 ORDER BY 与 SEPARATOR, 分隔符保留在模板中, 默认分隔符 ',' 省略
+//
+// e.g. GROUP_CONCAT(DISTINCT name ORDER BY id DESC SEPARATOR ';')
+func (v *ExtractVisitor) appendGroupConcatOpts(node *ast.AggregateFuncExpr) {
+	if node.Order != nil {
+		v.builder.WriteString(" ORDER BY ")
+		v.appendByItems(node.Order.Items)
+	}
+
+	sep, ok := node.Args[len(node.Args)-1].(*test_driver.ValueExpr)
+	if !ok || sep.Kind() != test_driver.KindString {
+		v.reportUnhandled(node.Args[len(node.Args)-1], "unhandled group_concat separator")
+		return
+	}
+
+	v.markKeptLiteral(sep)
+	if sep.GetString() != "," {
+		v.builder.WriteString(" SEPARATOR ")
+		v.builder.WriteString(literal.Quote(sep.GetString()))
+	}
+}
+
 // handleCaseExpr 处理 CASE 表达式
 func (v *ExtractVisitor) handleCaseExpr(node *ast.CaseExpr) {
 	if node == nil {
 		return
 	}
 
+	defer v.enterLiteralCtx(LiteralCaseWhen)()
+
 	v.builder.WriteString("CASE")
 
 	// Simple CASE: CASE expr WHEN v1 THEN r1 [WHEN v2 THEN r2] [ELSE rn] END
@@ -886,7 +2134,17 @@ func (v *ExtractVisitor) handleParenthesesExpr(node *ast.ParenthesesExpr) {
 
 // handleFuncCallExpr 处理函数调用表达式
 func (v *ExtractVisitor) handleFuncCallExpr(node *ast.FuncCallExpr) {
-	v.builder.WriteString(node.FnName.String())
+	if v.handleSpecialFuncCall(node) {
+		return
+	}
+
+	// -> 与 ->> 被解析为小写的 json_extract / json_unquote 调用, 统一为大写, 与显式调用的模板一致
+	switch node.FnName.L {
+	case ast.JSONExtract, ast.JSONUnquote:
+		v.builder.WriteString(strings.ToUpper(node.FnName.L))
+	default:
+		v.builder.WriteString(node.FnName.String())
+	}
 	v.builder.WriteString("(")
 
 	for i := range len(node.Args) {
@@ -911,17 +2169,18 @@ func (v *ExtractVisitor) handleFuncCallExpr(node *ast.FuncCallExpr) {
 
 		// 如果是时间单位表达式，则特殊处理
 		if interval, ok := arg.(*ast.TimeUnitExpr); ok {
+			v.builder.WriteString("INTERVAL ")
+			v.appendPlaceholder("?")
 			if i > 0 {
 				// 检查前一个参数是否为值表达式
 				if _, prevIsValue := node.Args[i-1].(*test_driver.ValueExpr); prevIsValue {
 					// 如果前一个参数是值表达式，我们需要将其作为参数
 					if valExpr, ok := node.Args[i-1].(*test_driver.ValueExpr); ok {
-						v.params = append(v.params, valExpr.GetValue())
+						v.appendParam(valExpr.GetValue(), valExpr)
 					}
 				}
 			}
-			v.builder.WriteString("INTERVAL ")
-			v.builder.WriteString("? ")
+			v.builder.WriteString(" ")
 			v.builder.WriteString(interval.Unit.String())
 			continue
 		}
@@ -931,6 +2190,11 @@ func (v *ExtractVisitor) handleFuncCallExpr(node *ast.FuncCallExpr) {
 			continue
 		}
 
+		// JSON 路径按配置保留或参数化
+		if v.keepJSONPath(node, i) {
+			continue
+		}
+
 		// 处理其他类型的参数
 		arg.Accept(v)
 	}
@@ -940,7 +2204,11 @@ func (v *ExtractVisitor) handleFuncCallExpr(node *ast.FuncCallExpr) {
 
 // handleUnaryOperationExpr 处理一元操作表达式
 func (v *ExtractVisitor) handleUnaryOperationExpr(node *ast.UnaryOperationExpr) {
-	v.builder.WriteString(node.Op.String())
+	if op, ok := logicOpMap[node.Op]; ok {
+		defer v.enterLogicOp(op)()
+	}
+
+	v.builder.WriteString(v.operator(node.Op))
 	v.builder.WriteString(" ")
 	node.V.Accept(v)
 }
@@ -1007,7 +2275,7 @@ func (v *ExtractVisitor) handleCompareSubqueryExpr(node *ast.CompareSubqueryExpr
 	node.L.Accept(v)
 
 	v.builder.WriteByte(' ')
-	v.builder.WriteString(node.Op.String())
+	v.builder.WriteString(v.operator(node.Op))
 
 	// 添加 ALL/ANY 关键字
 	if node.All {
@@ -1029,6 +2297,7 @@ func (v *ExtractVisitor) handleShowStmt(node *ast.ShowStmt) {
 	}
 
 	v.builder.WriteString("SHOW ")
+	v.access = models.AccessMetadata
 
 	// 处理不同类型的 SHOW 语句
 	switch node.Tp {
@@ -1056,7 +2325,7 @@ func (v *ExtractVisitor) handleShowStmt(node *ast.ShowStmt) {
 		v.handleShowWarningsOrErrors(node)
 	default:
 		// 其他 SHOW 语句类型的处理可以在这里添加
-		v.logError(fmt.Sprintf("Unhandled ShowStmt type: %v", node.Tp))
+		v.reportUnhandled(node, fmt.Sprintf("unhandled SHOW type: %v", node.Tp))
 	}
 }
 
@@ -1154,13 +2423,17 @@ func (v *ExtractVisitor) handleShowWarningsOrErrors(node *ast.ShowStmt) {
 	}
 }
 
-// appendTableName 添加表名到 SQL 字符串
+// appendTableName 添加表名到 SQL 字符串, 用于 SHOW 语句, 表名不做分库分表模板化
 func (v *ExtractVisitor) appendTableName(table *ast.TableName) {
 	if table.Schema.O != "" {
 		v.builder.WriteString(table.Schema.O)
 		v.builder.WriteString(".")
 	}
 	v.builder.WriteString(table.Name.O)
+
+	ti := models.NewTableInfo(table.Schema.O, table.Name.O, table.Schema.O, table.Name.O)
+	ti.SetAccess(v.access)
+	v.tableInfos = append(v.tableInfos, ti)
 }
 
 // appendPatternAndWhere 添加 LIKE 和 WHERE 子句到 SQL 字符串
@@ -1168,8 +2441,8 @@ func (v *ExtractVisitor) appendPatternAndWhere(node *ast.ShowStmt) {
 	if node.Pattern != nil {
 		v.builder.WriteString(" LIKE ")
 		if valExpr, ok := node.Pattern.Pattern.(*test_driver.ValueExpr); ok {
-			v.builder.WriteString("?")
-			v.params = append(v.params, valExpr.GetValue())
+			v.appendPlaceholder("?")
+			v.appendParam(valExpr.GetValue(), valExpr)
 		} else {
 			node.Pattern.Pattern.Accept(v)
 		}
@@ -1191,8 +2464,85 @@ func (v *ExtractVisitor) handlePositionExpr(node *ast.PositionExpr) {
 	}
 }
 
-// FIXME logError logs unhandled node type errors during SQL templatization
-func (v *ExtractVisitor) logError(details string) {
-	msg := "[SQL Templatize Error] unhandled node type: " + details
-	fmt.Println(msg)
+// handleRowExpr 处理行构造表达式
+//
+// e.g. (a, b) = (?, ?), ROW(a, b)
+func (v *ExtractVisitor) handleRowExpr(node *ast.RowExpr) {
+	v.builder.WriteString("(")
+	for idx := range node.Values {
+		if idx > 0 {
+			v.builder.WriteString(", ")
+		}
+
+		node.Values[idx].Accept(v)
+	}
+	v.builder.WriteString(")")
+}
+
+// handleVariableExpr 处理用户变量及系统变量
+//
+// e.g. @rank, @rank := @rank + ?, @@sql_mode, @@session.sql_mode, @@global.max_connections
+func (v *ExtractVisitor) handleVariableExpr(node *ast.VariableExpr) {
+	if node.IsSystem {
+		v.builder.WriteString("@@")
+		if node.ExplicitScope {
+			if node.IsGlobal {
+				v.builder.WriteString("global.")
+			} else {
+				v.builder.WriteString("session.")
+			}
+		}
+	} else {
+		v.builder.WriteString("@")
+	}
+	v.builder.WriteString(node.Name)
+
+	// 赋值: @rank := @rank + 1
+	if node.Value != nil {
+		v.builder.WriteString(" := ")
+		node.Value.Accept(v)
+	}
+}
+
+// handleMatchAgainst 处理全文检索表达式, 检索内容作为参数
+//
+// e.g. MATCH (title, body) AGAINST (? IN BOOLEAN MODE)
+func (v *ExtractVisitor) handleMatchAgainst(node *ast.MatchAgainst) {
+	v.builder.WriteString("MATCH (")
+	for idx := range node.ColumnNames {
+		if idx > 0 {
+			v.builder.WriteString(", ")
+		}
+
+		v.handleColumnNameExpr(&ast.ColumnNameExpr{Name: node.ColumnNames[idx]})
+	}
+	v.builder.WriteString(") AGAINST (")
+
+	node.Against.Accept(v)
+
+	if node.Modifier.IsBooleanMode() {
+		v.builder.WriteString(" IN BOOLEAN MODE")
+	} else if node.Modifier.WithQueryExpansion() {
+		v.builder.WriteString(" WITH QUERY EXPANSION")
+	}
+	v.builder.WriteString(")")
+}
+
+// handleSetCollationExpr 处理 COLLATE 表达式
+//
+// e.g. name COLLATE utf8mb4_bin eq ?
+func (v *ExtractVisitor) handleSetCollationExpr(node *ast.SetCollationExpr) {
+	node.Expr.Accept(v)
+	v.builder.WriteString(" COLLATE ")
+	v.builder.WriteString(node.Collate)
+}
+
+// conf 返回 visitor 的配置, 未由 Extractor 创建的 visitor 使用默认配置
+func (v *ExtractVisitor) conf() *config {
+	if v.cfg == nil {
+		cfg := defaultConfig()
+		return &cfg
+	}
+
+	return v.cfg
 }
@@ -1,11 +1,20 @@
 package extract
 
 import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
 	"testing"
 
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/parser/mysql"
+	"github.com/samber/lo"
 	"github.com/stretchr/testify/assert"
 
-	"github.com/kydenul/sql-extractor/internal/models"
+	"github.com/kydenul/sql-extractor/internal/testutil"
+	"github.com/kydenul/sql-extractor/models"
 )
 
 func TestTemplatizeSQL_empty(t *testing.T) {
@@ -1134,7 +1143,7 @@ func TestTemplatizeSQL_Insert(t *testing.T) {
 	)
 	as.Equal(6, len(params[0]))
 	as.Equal([][]*models.TableInfo{{
-		models.NewTableInfo("", "users", "", "users"),
+		testutil.WithAccess(models.NewTableInfo("", "users", "", "users"), models.AccessWrite),
 	}}, tableInfos)
 	as.Equal([]models.SQLOpType{models.SQLOperationInsert}, op)
 	as.Equal([]bool{false}, pms)
@@ -1148,7 +1157,7 @@ func TestTemplatizeSQL_Insert(t *testing.T) {
 		template)
 	as.Equal(6, len(params[0]))
 	as.Equal([][]*models.TableInfo{{
-		models.NewTableInfo("", "users", "", "users"),
+		testutil.WithAccess(models.NewTableInfo("", "users", "", "users"), models.AccessWrite),
 	}}, tableInfos)
 	as.Equal([]models.SQLOpType{models.SQLOperationInsert}, op)
 	as.Equal([]bool{false}, pms)
@@ -1165,7 +1174,7 @@ func TestTemplatizeSQL_Insert(t *testing.T) {
 	)
 	as.Equal(12, len(params[0]))
 	as.Equal([][]*models.TableInfo{{
-		models.NewTableInfo("", "users", "", "users"),
+		testutil.WithAccess(models.NewTableInfo("", "users", "", "users"), models.AccessWrite),
 	}}, tableInfos)
 	as.Equal([]models.SQLOpType{models.SQLOperationInsert}, op)
 	as.Equal([]bool{false}, pms)
@@ -1182,7 +1191,7 @@ func TestTemplatizeSQL_Insert(t *testing.T) {
 	)
 	as.Equal(15, len(params[0]))
 	as.Equal([][]*models.TableInfo{{
-		models.NewTableInfo("", "users", "", "users"),
+		testutil.WithAccess(models.NewTableInfo("", "users", "", "users"), models.AccessReadWrite),
 	}}, tableInfos)
 	as.Equal([]models.SQLOpType{models.SQLOperationInsert}, op)
 	as.Equal([]bool{false}, pms)
@@ -1199,7 +1208,7 @@ func TestTemplatizeSQL_Insert(t *testing.T) {
 	)
 	as.Equal(6, len(params[0]))
 	as.Equal([][]*models.TableInfo{{
-		models.NewTableInfo("", "users", "", "users"),
+		testutil.WithAccess(models.NewTableInfo("", "users", "", "users"), models.AccessWrite),
 	}}, tableInfos)
 	as.Equal([]models.SQLOpType{models.SQLOperationInsert}, op)
 	as.Equal([]bool{false}, pms)
@@ -1216,7 +1225,7 @@ func TestTemplatizeSQL_Insert(t *testing.T) {
 	)
 	as.Equal(15, len(params[0]))
 	as.Equal([][]*models.TableInfo{{
-		models.NewTableInfo("", "users", "", "users"),
+		testutil.WithAccess(models.NewTableInfo("", "users", "", "users"), models.AccessReadWrite),
 	}}, tableInfos)
 	as.Equal([]models.SQLOpType{models.SQLOperationInsert}, op)
 	as.Equal([]bool{false}, pms)
@@ -1233,7 +1242,7 @@ func TestTemplatizeSQL_Insert(t *testing.T) {
 	)
 	as.Equal(15, len(params[0]))
 	as.Equal([][]*models.TableInfo{{
-		models.NewTableInfo("", "users", "", "users"),
+		testutil.WithAccess(models.NewTableInfo("", "users", "", "users"), models.AccessReadWrite),
 	}}, tableInfos)
 	as.Equal([]models.SQLOpType{models.SQLOperationInsert}, op)
 	as.Equal([]bool{false}, pms)
@@ -1247,7 +1256,7 @@ func TestTemplatizeSQL_Insert(t *testing.T) {
 		template)
 	as.Equal(0, len(params[0]))
 	as.Equal([][]*models.TableInfo{{
-		models.NewTableInfo("", "users", "", "users"),
+		testutil.WithAccess(models.NewTableInfo("", "users", "", "users"), models.AccessWrite),
 	}},
 		tableInfos)
 	as.Equal([]models.SQLOpType{models.SQLOperationInsert}, op)
@@ -1269,7 +1278,7 @@ ON DUPLICATE KEY UPDATE
 	)
 	as.Equal(1, len(params[0]))
 	as.Equal([][]*models.TableInfo{{
-		models.NewTableInfo("", "users", "", "users"),
+		testutil.WithAccess(models.NewTableInfo("", "users", "", "users"), models.AccessReadWrite),
 	}}, tableInfos)
 	as.Equal([]models.SQLOpType{models.SQLOperationInsert}, op)
 	as.Equal([]bool{false}, pms)
@@ -1291,7 +1300,7 @@ func TestTemplatizeSQL_Update(t *testing.T) {
 	)
 	as.Equal(7, len(params[0]))
 	as.Equal([][]*models.TableInfo{{
-		models.NewTableInfo("", "users", "", "users"),
+		testutil.WithAccess(models.NewTableInfo("", "users", "", "users"), models.AccessWrite),
 	}}, tableInfos)
 	as.Equal([]models.SQLOpType{models.SQLOperationUpdate}, op)
 	as.Equal([]bool{false}, pms)
@@ -1308,7 +1317,7 @@ func TestTemplatizeSQL_Update(t *testing.T) {
 	)
 	as.Equal(6, len(params[0]))
 	as.Equal([][]*models.TableInfo{{
-		models.NewTableInfo("", "users", "", "users"),
+		testutil.WithAccess(models.NewTableInfo("", "users", "", "users"), models.AccessWrite),
 	}}, tableInfos)
 	as.Equal([]models.SQLOpType{models.SQLOperationUpdate}, op)
 	as.Equal([]bool{false}, pms)
@@ -1325,7 +1334,7 @@ func TestTemplatizeSQL_Update(t *testing.T) {
 	)
 	as.Equal(8, len(params[0]))
 	as.Equal([][]*models.TableInfo{{
-		models.NewTableInfo("", "users", "", "users"),
+		testutil.WithAccess(models.NewTableInfo("", "users", "", "users"), models.AccessWrite),
 	}}, tableInfos)
 	as.Equal([]models.SQLOpType{models.SQLOperationUpdate}, op)
 	as.Equal([]bool{false}, pms)
@@ -1342,7 +1351,7 @@ func TestTemplatizeSQL_Update(t *testing.T) {
 	)
 	as.Equal(7, len(params[0]))
 	as.Equal([][]*models.TableInfo{{
-		models.NewTableInfo("", "users", "", "users"),
+		testutil.WithAccess(models.NewTableInfo("", "users", "", "users"), models.AccessReadWrite),
 	}}, tableInfos)
 	as.Equal([]models.SQLOpType{models.SQLOperationUpdate}, op)
 	as.Equal([]bool{false}, pms)
@@ -1359,7 +1368,7 @@ func TestTemplatizeSQL_Update(t *testing.T) {
 	)
 	as.Equal(8, len(params[0]))
 	as.Equal([][]*models.TableInfo{{
-		models.NewTableInfo("", "users", "", "users"),
+		testutil.WithAccess(models.NewTableInfo("", "users", "", "users"), models.AccessReadWrite),
 	}}, tableInfos)
 	as.Equal([]models.SQLOpType{models.SQLOperationUpdate}, op)
 	as.Equal([]bool{false}, pms)
@@ -1376,7 +1385,7 @@ func TestTemplatizeSQL_Update(t *testing.T) {
 	)
 	as.Equal(8, len(params[0]))
 	as.Equal([][]*models.TableInfo{{
-		models.NewTableInfo("", "users", "", "users"),
+		testutil.WithAccess(models.NewTableInfo("", "users", "", "users"), models.AccessReadWrite),
 	}}, tableInfos)
 	as.Equal([]models.SQLOpType{models.SQLOperationUpdate}, op)
 	as.Equal([]bool{false}, pms)
@@ -1393,7 +1402,7 @@ func TestTemplatizeSQL_Update(t *testing.T) {
 	)
 	as.Equal(7, len(params[0]))
 	as.Equal([][]*models.TableInfo{{
-		models.NewTableInfo("", "users", "", "users"),
+		testutil.WithAccess(models.NewTableInfo("", "users", "", "users"), models.AccessWrite),
 	}}, tableInfos)
 	as.Equal([]models.SQLOpType{models.SQLOperationUpdate}, op)
 	as.Equal([]bool{false}, pms)
@@ -1410,7 +1419,7 @@ func TestTemplatizeSQL_Update(t *testing.T) {
 	)
 	as.Equal(7, len(params[0]))
 	as.Equal([][]*models.TableInfo{{
-		models.NewTableInfo("", "users", "", "users"),
+		testutil.WithAccess(models.NewTableInfo("", "users", "", "users"), models.AccessWrite),
 	}}, tableInfos)
 	as.Equal([]models.SQLOpType{models.SQLOperationUpdate}, op)
 	as.Equal([]bool{false}, pms)
@@ -1427,7 +1436,7 @@ func TestTemplatizeSQL_Update(t *testing.T) {
 	)
 	as.Equal(1, len(params[0]))
 	as.Equal([][]*models.TableInfo{{
-		models.NewTableInfo("", "users", "", "users"),
+		testutil.WithAccess(models.NewTableInfo("", "users", "", "users"), models.AccessReadWrite),
 	}}, tableInfos)
 	as.Equal([]models.SQLOpType{models.SQLOperationUpdate}, op)
 	as.Equal([]bool{false}, pms)
@@ -1444,7 +1453,7 @@ func TestTemplatizeSQL_Update(t *testing.T) {
 	)
 	as.Equal(7, len(params[0]))
 	as.Equal([][]*models.TableInfo{{
-		models.NewTableInfo("", "users", "", "users"),
+		testutil.WithAccess(models.NewTableInfo("", "users", "", "users"), models.AccessReadWrite),
 	}}, tableInfos)
 	as.Equal([]models.SQLOpType{models.SQLOperationUpdate}, op)
 	as.Equal([]bool{false}, pms)
@@ -1461,7 +1470,7 @@ func TestTemplatizeSQL_Update(t *testing.T) {
 	)
 	as.Equal(4, len(params[0]))
 	as.Equal([][]*models.TableInfo{{
-		models.NewTableInfo("", "users", "", "users"),
+		testutil.WithAccess(models.NewTableInfo("", "users", "", "users"), models.AccessReadWrite),
 	}}, tableInfos)
 	as.Equal([]models.SQLOpType{models.SQLOperationUpdate}, op)
 	as.Equal([]bool{false}, pms)
@@ -1485,7 +1494,7 @@ func TestTemplatizeSQL_ComplexUpdate(t *testing.T) {
 	as.Equal(6, len(params[0]))
 	as.Equal(
 		[][]*models.TableInfo{{
-			models.NewTableInfo("", "users", "", "users"),
+			testutil.WithAccess(models.NewTableInfo("", "users", "", "users"), models.AccessWrite),
 		}}, tableInfos)
 	as.Equal([]models.SQLOpType{models.SQLOperationUpdate}, op)
 	as.Equal([]bool{false}, pms)
@@ -1502,7 +1511,7 @@ func TestTemplatizeSQL_ComplexUpdate(t *testing.T) {
 	)
 	as.Equal(4, len(params[0]))
 	as.Equal([][]*models.TableInfo{{
-		models.NewTableInfo("", "users", "", "users"),
+		testutil.WithAccess(models.NewTableInfo("", "users", "", "users"), models.AccessReadWrite),
 	}}, tableInfos)
 	as.Equal([]models.SQLOpType{models.SQLOperationUpdate}, op)
 	as.Equal([]bool{false}, pms)
@@ -1525,7 +1534,7 @@ func TestTemplatizeSQL_case_when(t *testing.T) {
 	)
 	as.Equal(7, len(params[0]))
 	as.Equal([][]*models.TableInfo{{
-		models.NewTableInfo("", "users", "", "users"),
+		testutil.WithAccess(models.NewTableInfo("", "users", "", "users"), models.AccessWrite),
 	}}, tableInfos)
 	as.Equal([]models.SQLOpType{models.SQLOperationUpdate}, op)
 	as.Equal([]bool{false}, pms)
@@ -1542,7 +1551,7 @@ func TestTemplatizeSQL_case_when(t *testing.T) {
 	)
 	as.Equal(6, len(params[0]))
 	as.Equal([][]*models.TableInfo{{
-		models.NewTableInfo("", "users", "", "users"),
+		testutil.WithAccess(models.NewTableInfo("", "users", "", "users"), models.AccessWrite),
 	}}, tableInfos)
 	as.Equal([]models.SQLOpType{models.SQLOperationUpdate}, op)
 	as.Equal([]bool{false}, pms)
@@ -1561,7 +1570,7 @@ func TestTemplatizeSQL_Delete(t *testing.T) {
 		template)
 	as.Equal(1, len(params[0]))
 	as.Equal([][]*models.TableInfo{{
-		models.NewTableInfo("", "users", "", "users"),
+		testutil.WithAccess(models.NewTableInfo("", "users", "", "users"), models.AccessWrite),
 	}}, tableInfos)
 	as.Equal([]models.SQLOpType{models.SQLOperationDelete}, op)
 	as.Equal([]bool{false}, pms)
@@ -1575,7 +1584,7 @@ func TestTemplatizeSQL_Delete(t *testing.T) {
 		template)
 	as.Equal(0, len(params[0]))
 	as.Equal([][]*models.TableInfo{{
-		models.NewTableInfo("", "users", "", "users"),
+		testutil.WithAccess(models.NewTableInfo("", "users", "", "users"), models.AccessWrite),
 	}}, tableInfos)
 	as.Equal([]models.SQLOpType{models.SQLOperationDelete}, op)
 	as.Equal([]bool{false}, pms)
@@ -1592,8 +1601,7 @@ func TestTemplatizeSQL_Delete(t *testing.T) {
 	)
 	as.Equal(1, len(params[0]))
 	as.Equal([][]*models.TableInfo{{
-		models.NewTableInfo("", "u", "", "u"),
-		models.NewTableInfo("", "users", "", "users"),
+		testutil.WithAccess(models.NewTableInfo("", "users", "", "users"), models.AccessWrite),
 		models.NewTableInfo("", "roles", "", "roles"),
 	}}, tableInfos)
 	as.Equal([]models.SQLOpType{models.SQLOperationDelete}, op)
@@ -1608,7 +1616,7 @@ func TestTemplatizeSQL_Delete(t *testing.T) {
 		template)
 	as.Equal(2, len(params[0]))
 	as.Equal([][]*models.TableInfo{{
-		models.NewTableInfo("", "users", "", "users"),
+		testutil.WithAccess(models.NewTableInfo("", "users", "", "users"), models.AccessWrite),
 	}}, tableInfos)
 	as.Equal([]models.SQLOpType{models.SQLOperationDelete}, op)
 	as.Equal([]bool{false}, pms)
@@ -1622,7 +1630,7 @@ func TestTemplatizeSQL_Delete(t *testing.T) {
 		template)
 	as.Equal(1, len(params))
 	as.Equal([][]*models.TableInfo{{
-		models.NewTableInfo("", "users", "", "users"),
+		testutil.WithAccess(models.NewTableInfo("", "users", "", "users"), models.AccessWrite),
 	}}, tableInfos)
 	as.Equal([]models.SQLOpType{models.SQLOperationDelete}, op)
 	as.Equal([]bool{false}, pms)
@@ -1637,7 +1645,7 @@ func TestTemplatizeSQL_Delete(t *testing.T) {
 	)
 	as.Equal(1, len(params[0]))
 	as.Equal([][]*models.TableInfo{{
-		models.NewTableInfo("", "users", "", "users"),
+		testutil.WithAccess(models.NewTableInfo("", "users", "", "users"), models.AccessWrite),
 		models.NewTableInfo("", "roles", "", "roles"),
 	}}, tableInfos)
 	as.Equal([]models.SQLOpType{models.SQLOperationDelete}, op)
@@ -1655,15 +1663,13 @@ func TestTemplatizeSQL_Delete(t *testing.T) {
 	)
 	as.Equal(1, len(params[0]))
 	as.Equal([][]*models.TableInfo{{
-		models.NewTableInfo("", "u", "", "u"),
-		models.NewTableInfo("", "r", "", "r"),
-		models.NewTableInfo("", "users", "", "users"),
-		models.NewTableInfo("", "roles", "", "roles"),
+		testutil.WithAccess(models.NewTableInfo("", "users", "", "users"), models.AccessWrite),
+		testutil.WithAccess(models.NewTableInfo("", "roles", "", "roles"), models.AccessWrite),
 	}}, tableInfos)
 	as.Equal([]models.SQLOpType{models.SQLOperationDelete}, op)
 	as.Equal([]bool{false}, pms)
 
-	// FIXME delete alias
+	// DELETE 的目标为别名时不计入表信息
 	sql = "DELETE u FROM users u INNER JOIN roles r ON u.id = r.user_id WHERE u.uuid = 'kytedance'"
 	template, tableInfos, params, op, pms, err = NewExtractor().Extract(sql)
 	as.Equal(nil, err)
@@ -1675,8 +1681,7 @@ func TestTemplatizeSQL_Delete(t *testing.T) {
 	)
 	as.Equal(1, len(params[0]))
 	as.Equal([][]*models.TableInfo{{
-		models.NewTableInfo("", "u", "", "u"),
-		models.NewTableInfo("", "users", "", "users"),
+		testutil.WithAccess(models.NewTableInfo("", "users", "", "users"), models.AccessWrite),
 		models.NewTableInfo("", "roles", "", "roles"),
 	}}, tableInfos)
 	as.Equal([]models.SQLOpType{models.SQLOperationDelete}, op)
@@ -1700,7 +1705,7 @@ func TestTemplatizeSQL_complex(t *testing.T) {
 	)
 	as.Equal(10, len(params[0]))
 	as.Equal([][]*models.TableInfo{{
-		models.NewTableInfo("", "tb6", "", "tb6"),
+		testutil.WithAccess(models.NewTableInfo("", "tb6", "", "tb6"), models.AccessWrite),
 	}}, tableInfos)
 	as.Equal([]models.SQLOpType{models.SQLOperationInsert}, op)
 	as.Equal([]bool{false}, pms)
@@ -1728,14 +1733,14 @@ func TestTemplatizeSQL_MultipleStatements(t *testing.T) {
 	as.Equal(3, len(params))
 	as.Equal("Alice", params[0][0])
 	as.Equal(int64(25), params[0][1])
-	as.Equal("Alice", params[1][0])
-	as.Equal(int64(25), params[1][1])
+	as.Equal(int64(26), params[1][0])
+	as.Equal("Alice", params[1][1])
 	as.Equal("Alice", params[2][0])
 	as.Equal(int64(25), params[2][1])
 	as.Equal([][]*models.TableInfo{
-		{models.NewTableInfo("", "users", "", "users")},
-		{models.NewTableInfo("", "users", "", "users")},
-		{models.NewTableInfo("", "users", "", "users")},
+		{testutil.WithAccess(models.NewTableInfo("", "users", "", "users"), models.AccessWrite)},
+		{testutil.WithAccess(models.NewTableInfo("", "users", "", "users"), models.AccessWrite)},
+		{testutil.WithAccess(models.NewTableInfo("", "users", "", "users"), models.AccessWrite)},
 	}, tableInfos)
 	as.Equal(
 		[]models.SQLOpType{
@@ -1768,8 +1773,8 @@ func TestTemplatizeSQL_MultipleStatements(t *testing.T) {
 	as.Equal(10, len(params[0]))
 	as.Equal(10, len(params[1]))
 	as.Equal([][]*models.TableInfo{
-		{models.NewTableInfo("", "tbTradiQueueRT_6", "", "tbTradiQueueRT_?")},
-		{models.NewTableInfo("", "tbTradiQueueUK", "", "tbTradiQueueUK")},
+		{testutil.WithShards(testutil.WithAccess(models.NewTableInfo("", "tbTradiQueueRT_6", "", "tbTradiQueueRT_?"), models.AccessWrite), nil, []string{"6"})},
+		{testutil.WithAccess(models.NewTableInfo("", "tbTradiQueueUK", "", "tbTradiQueueUK"), models.AccessWrite)},
 	}, tableInfos)
 	as.Equal([]models.SQLOpType{models.SQLOperationInsert, models.SQLOperationInsert}, op)
 	as.Equal([]bool{false, false}, pms)
@@ -2210,7 +2215,7 @@ func TestTemplatizeSQL_Default(t *testing.T) {
 	as.Equal(1, len(params[0]))
 	as.Equal("Alice", params[0][0])
 	as.Equal([][]*models.TableInfo{{
-		models.NewTableInfo("", "users", "", "users"),
+		testutil.WithAccess(models.NewTableInfo("", "users", "", "users"), models.AccessWrite),
 	}}, tableInfos)
 	as.Equal([]models.SQLOpType{models.SQLOperationInsert}, op)
 	as.Equal([]bool{false}, pms)
@@ -2226,7 +2231,7 @@ func TestTemplatizeSQL_Default(t *testing.T) {
 	as.Equal(1, len(params))
 	as.Equal(0, len(params[0]))
 	as.Equal([][]*models.TableInfo{{
-		models.NewTableInfo("", "users", "", "users"),
+		testutil.WithAccess(models.NewTableInfo("", "users", "", "users"), models.AccessWrite),
 	}}, tableInfos)
 	as.Equal([]models.SQLOpType{models.SQLOperationInsert}, op)
 	as.Equal([]bool{false}, pms)
@@ -2243,7 +2248,7 @@ func TestTemplatizeSQL_Default(t *testing.T) {
 	as.Equal("Alice", params[0][0])
 	as.Equal(int64(25), params[0][1])
 	as.Equal([][]*models.TableInfo{{
-		models.NewTableInfo("", "users", "", "users"),
+		testutil.WithAccess(models.NewTableInfo("", "users", "", "users"), models.AccessWrite),
 	}}, tableInfos)
 	as.Equal([]models.SQLOpType{models.SQLOperationInsert}, op)
 	as.Equal([]bool{false}, pms)
@@ -2260,7 +2265,7 @@ func TestTemplatizeSQL_Default(t *testing.T) {
 	as.Equal(1, len(params[0]))
 	as.Equal(int64(26), params[0][0])
 	as.Equal([][]*models.TableInfo{{
-		models.NewTableInfo("", "users", "", "users"),
+		testutil.WithAccess(models.NewTableInfo("", "users", "", "users"), models.AccessWrite),
 	}}, tableInfos)
 	as.Equal([]models.SQLOpType{models.SQLOperationInsert}, op)
 	as.Equal([]bool{false}, pms)
@@ -2329,7 +2334,7 @@ func TestTemplatizeSQL_TimeUnit(t *testing.T) {
 	as.Equal(1, len(params))
 	as.Equal(1, len(params[0]))
 	as.Equal([][]*models.TableInfo{{
-		models.NewTableInfo("", "tasks", "", "tasks"),
+		testutil.WithAccess(models.NewTableInfo("", "tasks", "", "tasks"), models.AccessWrite),
 	}}, tableInfos)
 	as.Equal([]models.SQLOpType{models.SQLOperationUpdate}, op)
 	as.Equal([]bool{false}, pms)
@@ -2398,7 +2403,7 @@ func TestTemplatizeSQL_Explain(t *testing.T) {
 	as.Equal(1, len(params))
 	as.Equal(1, len(params[0]))
 	as.Equal([][]*models.TableInfo{{
-		models.NewTableInfo("", "users", "", "users"),
+		testutil.WithAccess(models.NewTableInfo("", "users", "", "users"), models.AccessMetadata),
 	}}, tableInfos)
 	as.Equal([]models.SQLOpType{models.SQLOperationExplain}, op)
 	as.Equal([]bool{false}, pms)
@@ -2428,7 +2433,7 @@ func TestTemplatizeSQL_Explain(t *testing.T) {
 	as.Equal(1, len(params))
 	as.Equal(3, len(params[0]))
 	as.Equal([][]*models.TableInfo{{
-		models.NewTableInfo("", "users", "", "users"),
+		testutil.WithAccess(models.NewTableInfo("", "users", "", "users"), models.AccessMetadata),
 	}}, tableInfos)
 	as.Equal([]models.SQLOpType{models.SQLOperationExplain}, op)
 	as.Equal([]bool{false}, pms)
@@ -2535,6 +2540,58 @@ func TestTemplatizeSQL_RightJoin(t *testing.T) {
 	as.Equal([]bool{false}, pms)
 }
 
+func TestTemplatizeSQL_JoinSpecification(t *testing.T) {
+	t.Parallel()
+	as := assert.New(t)
+	parser := NewExtractor()
+
+	tests := []struct {
+		sql      string
+		template string
+	}{
+		{
+			"SELECT * FROM users JOIN orders USING (id, shop_id) WHERE users.age > 18",
+			"SELECT * FROM users CROSS JOIN orders USING (id, shop_id) WHERE users.age gt ?",
+		},
+		{"SELECT * FROM users NATURAL JOIN orders", "SELECT * FROM users NATURAL JOIN orders"},
+		{"SELECT * FROM users NATURAL LEFT JOIN orders", "SELECT * FROM users NATURAL LEFT JOIN orders"},
+		{"SELECT * FROM users NATURAL RIGHT OUTER JOIN orders", "SELECT * FROM users NATURAL RIGHT JOIN orders"},
+		{
+			"SELECT * FROM users STRAIGHT_JOIN orders ON users.id = orders.user_id",
+			"SELECT * FROM users STRAIGHT_JOIN orders ON users.id eq orders.user_id",
+		},
+		{
+			"SELECT * FROM users LEFT JOIN orders USING (id) NATURAL JOIN items",
+			"SELECT * FROM users LEFT JOIN orders USING (id) NATURAL JOIN items",
+		},
+	}
+
+	for _, tt := range tests {
+		template, _, _, _, _, err := parser.Extract(tt.sql)
+		as.Nil(err, tt.sql)
+		as.Equal([]string{tt.template}, template, tt.sql)
+	}
+
+	// USING 列记录在 ON 子句中, 归属 JOIN 两侧的表
+	stmts, err := parser.ExtractStatements("SELECT * FROM users JOIN orders USING (id)")
+	as.Nil(err)
+	as.Equal([]models.Column{
+		{Name: "*", Clause: models.ClauseSelect, Access: models.AccessRead, Tables: stmts[0].TableInfos},
+		{Name: "id", Clause: models.ClauseOn, Access: models.AccessRead, Tables: stmts[0].TableInfos},
+	}, stmts[0].Columns)
+
+	// RenderModeSQL 的模板可被重新解析, 且结果不变
+	sqlParser := NewExtractor(WithRenderMode(RenderModeSQL))
+	for _, tt := range tests {
+		template, _, _, _, _, err := sqlParser.Extract(tt.sql)
+		as.Nil(err, tt.sql)
+
+		again, _, _, _, _, err := sqlParser.Extract(template[0])
+		as.Nil(err, template[0])
+		as.Equal(template, again, tt.sql)
+	}
+}
+
 func TestTemplatizeSQL_UnaryOperations(t *testing.T) {
 	t.Parallel()
 	as := assert.New(t)
@@ -2724,11 +2781,147 @@ func TestTemplatizeSQL_TimeUnitExpr(t *testing.T) {
 	as.Equal([]bool{false}, pms)
 }
 
-func TestTemplatizeVisitor_logError(t *testing.T) {
+func TestTemplatizeVisitor_reportUnhandled(t *testing.T) {
+	t.Parallel()
+	as := assert.New(t)
+
+	v := &ExtractVisitor{clause: models.ClauseWhere}
+	v.reportUnhandled(&ast.MatchAgainst{}, "test")
+	as.Equal([]models.Warning{{
+		NodeType: "*ast.MatchAgainst",
+		Clause:   models.ClauseWhere,
+		Message:  "test",
+	}}, v.warnings)
+}
+
+func TestTemplatizeSQL_Warnings(t *testing.T) {
+	t.Parallel()
+	as := assert.New(t)
+
+	var buf bytes.Buffer
+	psr := NewExtractor(WithLogger(slog.New(slog.NewJSONHandler(&buf, nil))))
+
+	sql := "SELECT 1; SELECT a FROM t WHERE NEXTVAL(s) AND b IN (SELECT c FROM t2 ORDER BY d) LIMIT 1;\n" +
+		"LOCK TABLES t READ"
+	stmts, err := psr.ExtractStatements(sql)
+	as.Nil(err)
+	as.Len(stmts, 3)
+	as.Empty(stmts[0].Warnings)
+
+	// 无位置信息的节点使用语句的起始位置
+	as.Equal([]models.Warning{{
+		StmtIndex: 1,
+		NodeType:  "*ast.TableNameExpr",
+		Offset:    strings.Index(sql, "SELECT a"),
+		Clause:    models.ClauseWhere,
+		Message:   "unhandled node type",
+	}}, stmts[1].Warnings)
+
+	as.Equal([]models.Warning{{
+		StmtIndex: 2,
+		NodeType:  "*ast.LockTablesStmt",
+		Offset:    strings.Index(sql, "LOCK"),
+		Clause:    models.ClauseNone,
+		Message:   "unhandled node type",
+	}}, stmts[2].Warnings)
+
+	as.Contains(buf.String(), `"node_type":"*ast.TableNameExpr"`)
+	as.Contains(buf.String(), `"clause":"WHERE"`)
+	as.Contains(buf.String(), `"stmt_index":2`)
+
+	// 子查询的子句不外泄
+	stmts, err = psr.ExtractStatements("SELECT * FROM t WHERE a IN (SELECT b FROM t2 LIMIT 1) AND NEXTVAL(s) = 1")
+	as.Nil(err)
+	as.Len(stmts[0].Warnings, 1)
+	as.Equal(models.ClauseWhere, stmts[0].Warnings[0].Clause)
+
+	stmts, err = psr.ExtractStatements("SELECT * FROM t1 JOIN t2 ON t1.a = NEXTVAL(s) WHERE t1.b = 1")
+	as.Nil(err)
+	as.Len(stmts[0].Warnings, 1)
+	as.Equal(models.ClauseOn, stmts[0].Warnings[0].Clause)
+	as.Equal("*ast.TableNameExpr", stmts[0].Warnings[0].NodeType)
+
+	// strict
+	strict := NewExtractor(WithStrict(true))
+	_, err = strict.ExtractStatements("SELECT * FROM t WHERE a = 1")
+	as.Nil(err)
+
+	_, err = strict.ExtractStatements(sql)
+	var unhandled *UnhandledNodeError
+	as.ErrorAs(err, &unhandled)
+	as.Len(unhandled.Warnings, 2)
+	as.Equal(
+		"unhandled node: statement 1, offset 10 in WHERE: *ast.TableNameExpr: unhandled node type (and 1 more)",
+		err.Error(),
+	)
+
+	_, _, _, _, _, err = strict.Extract("SELECT NEXTVAL(s)")
+	as.ErrorAs(err, &unhandled)
+	as.Equal("unhandled node: statement 0, offset 0 in SELECT: *ast.TableNameExpr: unhandled node type", err.Error())
+}
+
+func TestTemplatizeSQL_StrictSelect(t *testing.T) {
 	t.Parallel()
+	as := assert.New(t)
+	strict := NewExtractor(WithStrict(true))
+
+	tests := []struct {
+		sql      string
+		template string
+	}{
+		{"SELECT * FROM t WHERE id = 1 FOR UPDATE", "SELECT * FROM t WHERE id eq ? FOR UPDATE"},
+		{"SELECT * FROM t WHERE id = 1 LOCK IN SHARE MODE", "SELECT * FROM t WHERE id eq ? FOR SHARE"},
+		{"SELECT * FROM t AS a FOR UPDATE OF a NOWAIT", "SELECT * FROM t AS a FOR UPDATE OF a NOWAIT"},
+		{"SELECT * FROM t FOR SHARE SKIP LOCKED", "SELECT * FROM t FOR SHARE SKIP LOCKED"},
+		{"SELECT * FROM t FOR UPDATE WAIT 5", "SELECT * FROM t FOR UPDATE WAIT 5"},
+		{"SELECT a, COUNT(*) FROM t GROUP BY a WITH ROLLUP", "SELECT a, COUNT(1) FROM t GROUP BY a WITH ROLLUP"},
+		{"SELECT SQL_CALC_FOUND_ROWS * FROM t LIMIT 10", "SELECT SQL_CALC_FOUND_ROWS * FROM t LIMIT ?"},
+		{
+			"SELECT DISTINCT HIGH_PRIORITY SQL_NO_CACHE SQL_BUFFER_RESULT a FROM t",
+			"SELECT DISTINCT HIGH_PRIORITY SQL_BUFFER_RESULT SQL_NO_CACHE a FROM t",
+		},
+		{
+			"SELECT * FROM t AS a USE INDEX (idx_a, idx_b) FORCE INDEX FOR ORDER BY (idx_c) WHERE a = 1",
+			"SELECT * FROM t AS a USE INDEX (idx_a, idx_b) FORCE INDEX FOR ORDER BY (idx_c) WHERE a eq ?",
+		},
+		{
+			"SELECT * FROM t PARTITION (p0, p1) AS x IGNORE INDEX (i) WHERE a = 1",
+			"SELECT * FROM t PARTITION (p0, p1) AS x IGNORE INDEX (i) WHERE a eq ?",
+		},
+		{"SELECT * FROM t1 JOIN t2 USING (id)", "SELECT * FROM t1 CROSS JOIN t2 USING (id)"},
+		{"SELECT * FROM t1 NATURAL LEFT JOIN t2", "SELECT * FROM t1 NATURAL LEFT JOIN t2"},
+		{
+			"SELECT GROUP_CONCAT(DISTINCT a ORDER BY b DESC, c SEPARATOR ';') FROM t",
+			"SELECT GROUP_CONCAT(DISTINCT a ORDER BY b DESC, c SEPARATOR ';') FROM t",
+		},
+		// 默认分隔符省略
+		{"SELECT GROUP_CONCAT(a, b SEPARATOR ',') FROM t", "SELECT GROUP_CONCAT(a, b) FROM t"},
+	}
+
+	for _, tt := range tests {
+		template, _, _, _, _, err := strict.Extract(tt.sql)
+		as.Nil(err, tt.sql)
+		as.Equal([]string{tt.template}, template, tt.sql)
+	}
+
+	// 无法模板化的节点
+	unhandled := []struct {
+		sql      string
+		nodeType string
+	}{
+		{"SELECT * FROM t TABLESAMPLE REGIONS()", "*ast.TableSample"},
+		{"SELECT * FROM t AS OF TIMESTAMP '2024-01-01 00:00:00'", "*ast.AsOfClause"},
+		{"SELECT /*+ MAX_EXECUTION_TIME(1000) */ * FROM t", "*ast.TableOptimizerHint"},
+		{"SELECT * FROM t INTO OUTFILE '/tmp/t.txt'", "*ast.SelectIntoOption"},
+	}
 
-	v := &ExtractVisitor{}
-	v.logError("test")
+	for _, tt := range unhandled {
+		_, err := strict.ExtractStatements(tt.sql)
+		var unhandledErr *UnhandledNodeError
+		if as.ErrorAs(err, &unhandledErr, tt.sql) {
+			as.Equal(tt.nodeType, unhandledErr.Warnings[0].NodeType, tt.sql)
+		}
+	}
 }
 
 func TestTemplatizeSQL_EmptySpace(t *testing.T) {
@@ -2761,7 +2954,7 @@ func TestTemplatizeSQL_EmptySpace(t *testing.T) {
 	as.Equal(1, len(params))
 	as.Equal(2, len(params[0]))
 	as.Equal([][]*models.TableInfo{{
-		models.NewTableInfo("", "orders", "", "orders"),
+		testutil.WithAccess(models.NewTableInfo("", "orders", "", "orders"), models.AccessWrite),
 	}}, tableInfos)
 	as.Equal([]models.SQLOpType{models.SQLOperationInsert}, op)
 	as.Equal([]bool{false}, pms)
@@ -2776,7 +2969,7 @@ func TestTemplatizeSQL_EmptySpace(t *testing.T) {
 	as.Equal(1, len(params))
 	as.Equal(2, len(params[0]))
 	as.Equal([][]*models.TableInfo{{
-		models.NewTableInfo("", "orders", "", "orders"),
+		testutil.WithAccess(models.NewTableInfo("", "orders", "", "orders"), models.AccessWrite),
 	}}, tableInfos)
 	as.Equal([]models.SQLOpType{models.SQLOperationUpdate}, op)
 	as.Equal([]bool{false}, pms)
@@ -2791,7 +2984,7 @@ func TestTemplatizeSQL_EmptySpace(t *testing.T) {
 	as.Equal(1, len(params))
 	as.Equal(1, len(params[0]))
 	as.Equal([][]*models.TableInfo{{
-		models.NewTableInfo("", "orders", "", "orders"),
+		testutil.WithAccess(models.NewTableInfo("", "orders", "", "orders"), models.AccessWrite),
 	}}, tableInfos)
 	as.Equal([]models.SQLOpType{models.SQLOperationDelete}, op)
 	as.Equal([]bool{false}, pms)
@@ -2813,7 +3006,7 @@ func TestTemplatizeSQL_ShowStatements(t *testing.T) {
 	as.Equal(1, len(params))
 	as.Equal(0, len(params[0]))
 	as.Equal(1, len(tableInfos))
-	as.Equal(0, len(tableInfos[0])) // 应该没有表信息
+	as.Equal([]*models.TableInfo{testutil.WithAccess(models.NewTableInfo("", "tbUserTask_6", "", "tbUserTask_6"), models.AccessMetadata)}, tableInfos[0]) // SHOW 仅读取元数据, 表名不做分库分表模板化
 	as.Equal([]models.SQLOpType{models.SQLOperationShow}, op)
 	as.Equal([]bool{false}, pms)
 
@@ -2951,7 +3144,7 @@ func TestTemplatizeSQL_ShowStatements(t *testing.T) {
 	as.Equal(1, len(params))
 	as.Equal(0, len(params[0]))
 	as.Equal(1, len(tableInfos))
-	as.Equal(0, len(tableInfos[0]))
+	as.Equal([]*models.TableInfo{testutil.WithAccess(models.NewTableInfo("", "users", "", "users"), models.AccessMetadata)}, tableInfos[0])
 	as.Equal([]models.SQLOpType{models.SQLOperationShow}, op)
 	as.Equal([]bool{false}, pms)
 
@@ -2966,7 +3159,7 @@ func TestTemplatizeSQL_ShowStatements(t *testing.T) {
 	as.Equal(1, len(params))
 	as.Equal(0, len(params[0]))
 	as.Equal(1, len(tableInfos))
-	as.Equal(0, len(tableInfos[0]))
+	as.Equal([]*models.TableInfo{testutil.WithAccess(models.NewTableInfo("mydb", "users", "mydb", "users"), models.AccessMetadata)}, tableInfos[0])
 	as.Equal([]models.SQLOpType{models.SQLOperationShow}, op)
 	as.Equal([]bool{false}, pms)
 
@@ -2982,7 +3175,7 @@ func TestTemplatizeSQL_ShowStatements(t *testing.T) {
 	as.Equal(1, len(params[0]))
 	as.Equal("id%", params[0][0])
 	as.Equal(1, len(tableInfos))
-	as.Equal(0, len(tableInfos[0]))
+	as.Equal([]*models.TableInfo{testutil.WithAccess(models.NewTableInfo("", "users", "", "users"), models.AccessMetadata)}, tableInfos[0])
 	as.Equal([]models.SQLOpType{models.SQLOperationShow}, op)
 	as.Equal([]bool{false}, pms)
 
@@ -2997,7 +3190,7 @@ func TestTemplatizeSQL_ShowStatements(t *testing.T) {
 	as.Equal(1, len(params))
 	as.Equal(0, len(params[0]))
 	as.Equal(1, len(tableInfos))
-	as.Equal(0, len(tableInfos[0]))
+	as.Equal([]*models.TableInfo{testutil.WithAccess(models.NewTableInfo("", "users", "", "users"), models.AccessMetadata)}, tableInfos[0])
 	as.Equal([]models.SQLOpType{models.SQLOperationShow}, op)
 	as.Equal([]bool{false}, pms)
 
@@ -3150,7 +3343,7 @@ func TestExtractor_EscapedQuotes(t *testing.T) {
 	as.Equal([]models.SQLOpType{models.SQLOperationSelect}, op)
 	as.Equal(
 		[]string{
-			"SELECT * FROM tbGameCoinSerialV2 WHERE iStatus ne ? and dtCommitTime lt ? ORDER BY iSeqId LIMIT ?",
+			"SELECT * FROM tbGameCoinSerialV2 WHERE iStatus ne ? and dtCommitTime lt ? ORDER BY iSeqId ASC LIMIT ?",
 		},
 		template,
 	)
@@ -3305,7 +3498,7 @@ func TestExtractor_ComplexEscapeSequences(t *testing.T) {
 	)
 	as.Equal([][]any{{"Product with special features", int64(1)}}, params)
 	as.Equal(
-		[][]*models.TableInfo{{models.NewTableInfo("", "products", "", "products")}},
+		[][]*models.TableInfo{{testutil.WithAccess(models.NewTableInfo("", "products", "", "products"), models.AccessWrite)}},
 		tableInfos,
 	)
 	as.Equal([]bool{false}, pms)
@@ -3320,7 +3513,7 @@ func TestExtractor_ComplexEscapeSequences(t *testing.T) {
 		template,
 	)
 	as.Equal([][]any{{"New Years Eve", "Celebration on Dec 31st"}}, params)
-	as.Equal([][]*models.TableInfo{{models.NewTableInfo("", "events", "", "events")}}, tableInfos)
+	as.Equal([][]*models.TableInfo{{testutil.WithAccess(models.NewTableInfo("", "events", "", "events"), models.AccessWrite)}}, tableInfos)
 	as.Equal([]bool{false}, pms)
 
 	// Test SQL with both single and double quotes
@@ -3365,7 +3558,7 @@ func TestExtractor_ComplexEscapeSequences(t *testing.T) {
 func TestTemplateTable(t *testing.T) {
 	t.Parallel()
 	as := assert.New(t)
-	visitor := &ExtractVisitor{}
+	namer := NumericSuffixNamer()
 
 	testCases := []struct {
 		name           string
@@ -3462,10 +3655,10 @@ func TestTemplateTable(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(_ *testing.T) {
-			table := visitor.templateTable(tc.inputTable)
+			table, _ := templateShardName(namer, tc.inputTable)
 			as.Equal(tc.expectedTable, table)
 
-			schema := visitor.templateTable(tc.inputSchema)
+			schema, _ := templateShardName(namer, tc.inputSchema)
 			as.Equal(tc.expectedSchema, schema)
 		})
 	}
@@ -3708,3 +3901,1198 @@ func TestPatternRegexpExpr(t *testing.T) {
 	)
 	as.Equal([]bool{false}, pms)
 }
+
+func TestTemplatizeSQL_SetOpr(t *testing.T) {
+	t.Parallel()
+	as := assert.New(t)
+	extractor := NewExtractor()
+
+	// UNION ALL
+	sql := "SELECT a FROM t1 WHERE x = 1 UNION ALL SELECT b FROM t2 WHERE y = 'z'"
+	template, tableInfos, params, op, pms, err := extractor.Extract(sql)
+	as.Nil(err)
+	as.Equal([]models.SQLOpType{models.SQLOperationSelect}, op)
+	as.Equal(
+		[]string{"SELECT a FROM t1 WHERE x eq ? UNION ALL SELECT b FROM t2 WHERE y eq ?"},
+		template,
+	)
+	as.Equal([][]any{{int64(1), "z"}}, params)
+	as.Equal([][]*models.TableInfo{{
+		models.NewTableInfo("", "t1", "", "t1"),
+		models.NewTableInfo("", "t2", "", "t2"),
+	}}, tableInfos)
+	as.Equal([]bool{false}, pms)
+
+	// per-branch ORDER BY / LIMIT and trailing ORDER BY / LIMIT
+	sql = "(SELECT a FROM t1 ORDER BY a LIMIT 1) UNION ALL (SELECT b FROM t2 LIMIT 2) ORDER BY a DESC LIMIT 5"
+	template, tableInfos, params, op, pms, err = extractor.Extract(sql)
+	as.Nil(err)
+	as.Equal([]models.SQLOpType{models.SQLOperationSelect}, op)
+	as.Equal(
+		[]string{
+			"(SELECT a FROM t1 ORDER BY a LIMIT ?) UNION ALL (SELECT b FROM t2 LIMIT ?) ORDER BY a DESC LIMIT ?",
+		},
+		template,
+	)
+	as.Equal([][]any{{uint64(1), uint64(2), uint64(5)}}, params)
+	as.Equal([][]*models.TableInfo{{
+		models.NewTableInfo("", "t1", "", "t1"),
+		models.NewTableInfo("", "t2", "", "t2"),
+	}}, tableInfos)
+	as.Equal([]bool{false}, pms)
+
+	// nested parenthesised set operations
+	sql = "SELECT id FROM t1 WHERE k = 1 UNION (SELECT id FROM t2 EXCEPT SELECT id FROM t3 WHERE k > 3)"
+	template, tableInfos, params, op, pms, err = extractor.Extract(sql)
+	as.Nil(err)
+	as.Equal([]models.SQLOpType{models.SQLOperationSelect}, op)
+	as.Equal(
+		[]string{"SELECT id FROM t1 WHERE k eq ? UNION (SELECT id FROM t2 EXCEPT SELECT id FROM t3 WHERE k gt ?)"},
+		template,
+	)
+	as.Equal([][]any{{int64(1), int64(3)}}, params)
+	as.Equal([][]*models.TableInfo{{
+		models.NewTableInfo("", "t1", "", "t1"),
+		models.NewTableInfo("", "t2", "", "t2"),
+		models.NewTableInfo("", "t3", "", "t3"),
+	}}, tableInfos)
+	as.Equal([]bool{false}, pms)
+
+	// INTERSECT in subquery
+	sql = "SELECT a FROM t WHERE id IN (SELECT id FROM t1 INTERSECT SELECT id FROM t2)"
+	template, tableInfos, params, op, pms, err = extractor.Extract(sql)
+	as.Nil(err)
+	as.Equal([]models.SQLOpType{models.SQLOperationSelect}, op)
+	as.Equal(
+		[]string{"SELECT a FROM t WHERE id IN ((SELECT id FROM t1 INTERSECT SELECT id FROM t2))"},
+		template,
+	)
+	as.Equal([][]any{{}}, params)
+	as.Equal([][]*models.TableInfo{{
+		models.NewTableInfo("", "t", "", "t"),
+		models.NewTableInfo("", "t1", "", "t1"),
+		models.NewTableInfo("", "t2", "", "t2"),
+	}}, tableInfos)
+	as.Equal([]bool{false}, pms)
+
+	// derived table
+	sql = "SELECT * FROM (SELECT a FROM db_1.tb_2 UNION SELECT a FROM t2) AS x WHERE a > 1"
+	template, tableInfos, params, op, pms, err = extractor.Extract(sql)
+	as.Nil(err)
+	as.Equal([]models.SQLOpType{models.SQLOperationSelect}, op)
+	as.Equal(
+		[]string{"SELECT * FROM (SELECT a FROM db_?.tb_? UNION SELECT a FROM t2) AS x WHERE a gt ?"},
+		template,
+	)
+	as.Equal([][]any{{int64(1)}}, params)
+	as.Equal([][]*models.TableInfo{{
+		testutil.WithShards(models.NewTableInfo("db_1", "tb_2", "db_?", "tb_?"), []string{"1"}, []string{"2"}),
+		models.NewTableInfo("", "t2", "", "t2"),
+	}}, tableInfos)
+	as.Equal([]bool{false}, pms)
+}
+
+func TestTemplatizeSQL_With(t *testing.T) {
+	t.Parallel()
+	as := assert.New(t)
+	extractor := NewExtractor()
+
+	// simple CTE, CTE name is not reported as a table
+	sql := "WITH recent AS (SELECT * FROM orders WHERE ts > '2024-01-01') SELECT uid FROM recent WHERE amount > 10"
+	template, tableInfos, params, op, pms, err := extractor.Extract(sql)
+	as.Nil(err)
+	as.Equal([]models.SQLOpType{models.SQLOperationSelect}, op)
+	as.Equal(
+		[]string{"WITH recent AS (SELECT * FROM orders WHERE ts gt ?) SELECT uid FROM recent WHERE amount gt ?"},
+		template,
+	)
+	as.Equal([][]any{{"2024-01-01", int64(10)}}, params)
+	as.Equal([][]*models.TableInfo{{models.NewTableInfo("", "orders", "", "orders")}}, tableInfos)
+	as.Equal([]bool{false}, pms)
+
+	// recursive CTE with column list
+	sql = "WITH RECURSIVE seq (n) AS (SELECT 1 UNION ALL SELECT n + 1 FROM seq WHERE n < 5) SELECT * FROM seq"
+	template, tableInfos, params, op, pms, err = extractor.Extract(sql)
+	as.Nil(err)
+	as.Equal([]models.SQLOpType{models.SQLOperationSelect}, op)
+	as.Equal(
+		[]string{"WITH RECURSIVE seq (n) AS (SELECT ? UNION ALL SELECT n plus ? FROM seq WHERE n lt ?) SELECT * FROM seq"},
+		template,
+	)
+	as.Equal([][]any{{int64(1), int64(1), int64(5)}}, params)
+	as.Equal([][]*models.TableInfo{{}}, tableInfos)
+	as.Equal([]bool{false}, pms)
+
+	// multiple CTEs, the later one references the earlier one
+	sql = "WITH a AS (SELECT id FROM db_1.t1), b AS (SELECT a.id FROM a JOIN t2 ON a.id = t2.id) SELECT * FROM b"
+	template, tableInfos, params, op, pms, err = extractor.Extract(sql)
+	as.Nil(err)
+	as.Equal([]models.SQLOpType{models.SQLOperationSelect}, op)
+	as.Equal(
+		[]string{
+			"WITH a AS (SELECT id FROM db_?.t1), b AS (SELECT a.id FROM a CROSS JOIN t2 ON a.id eq t2.id) SELECT * FROM b",
+		},
+		template,
+	)
+	as.Equal([][]any{{}}, params)
+	as.Equal([][]*models.TableInfo{{
+		testutil.WithShards(models.NewTableInfo("db_1", "t1", "db_?", "t1"), []string{"1"}, nil),
+		models.NewTableInfo("", "t2", "", "t2"),
+	}}, tableInfos)
+	as.Equal([]bool{false}, pms)
+
+	// non-recursive CTE body references the physical table with the same name
+	sql = "WITH users AS (SELECT * FROM users WHERE age > 18) SELECT * FROM users"
+	template, tableInfos, params, op, pms, err = extractor.Extract(sql)
+	as.Nil(err)
+	as.Equal([]models.SQLOpType{models.SQLOperationSelect}, op)
+	as.Equal(
+		[]string{"WITH users AS (SELECT * FROM users WHERE age gt ?) SELECT * FROM users"},
+		template,
+	)
+	as.Equal([][]any{{int64(18)}}, params)
+	as.Equal([][]*models.TableInfo{{models.NewTableInfo("", "users", "", "users")}}, tableInfos)
+	as.Equal([]bool{false}, pms)
+
+	// CTE with set operation
+	sql = "WITH x AS (SELECT id FROM t1) SELECT id FROM x UNION SELECT id FROM t2"
+	template, tableInfos, params, op, pms, err = extractor.Extract(sql)
+	as.Nil(err)
+	as.Equal([]models.SQLOpType{models.SQLOperationSelect}, op)
+	as.Equal(
+		[]string{"WITH x AS (SELECT id FROM t1) SELECT id FROM x UNION SELECT id FROM t2"},
+		template,
+	)
+	as.Equal([][]any{{}}, params)
+	as.Equal([][]*models.TableInfo{{
+		models.NewTableInfo("", "t1", "", "t1"),
+		models.NewTableInfo("", "t2", "", "t2"),
+	}}, tableInfos)
+	as.Equal([]bool{false}, pms)
+
+	// CTE with UPDATE
+	sql = "WITH x AS (SELECT id FROM t1 WHERE k = 3) UPDATE t SET a = 1 WHERE id IN (SELECT id FROM x)"
+	template, tableInfos, params, op, pms, err = extractor.Extract(sql)
+	as.Nil(err)
+	as.Equal([]models.SQLOpType{models.SQLOperationUpdate}, op)
+	as.Equal(
+		[]string{"WITH x AS (SELECT id FROM t1 WHERE k eq ?) UPDATE t SET a eq ? WHERE id IN ((SELECT id FROM x))"},
+		template,
+	)
+	as.Equal([][]any{{int64(3), int64(1)}}, params)
+	as.Equal([][]*models.TableInfo{{
+		models.NewTableInfo("", "t1", "", "t1"),
+		testutil.WithAccess(models.NewTableInfo("", "t", "", "t"), models.AccessWrite),
+	}}, tableInfos)
+	as.Equal([]bool{false}, pms)
+
+	// CTE scope ends with its statement
+	sql = "SELECT * FROM (WITH x AS (SELECT id FROM t1) SELECT id FROM x) AS y JOIN x ON y.id = x.id"
+	template, tableInfos, params, op, pms, err = extractor.Extract(sql)
+	as.Nil(err)
+	as.Equal([]models.SQLOpType{models.SQLOperationSelect}, op)
+	as.Equal(
+		[]string{
+			"SELECT * FROM (WITH x AS (SELECT id FROM t1) SELECT id FROM x) AS y CROSS JOIN x ON y.id eq x.id",
+		},
+		template,
+	)
+	as.Equal([][]any{{}}, params)
+	as.Equal([][]*models.TableInfo{{
+		models.NewTableInfo("", "t1", "", "t1"),
+		models.NewTableInfo("", "x", "", "x"),
+	}}, tableInfos)
+	as.Equal([]bool{false}, pms)
+}
+
+func TestTemplatizeSQL_Replace(t *testing.T) {
+	t.Parallel()
+	as := assert.New(t)
+	extractor := NewExtractor()
+
+	// REPLACE ... VALUES
+	sql := "REPLACE INTO users (id, name) VALUES (1, 'kyden')"
+	template, tableInfos, params, op, pms, err := extractor.Extract(sql)
+	as.Nil(err)
+	as.Equal([]models.SQLOpType{models.SQLOperationReplace}, op)
+	as.Equal([]string{"REPLACE INTO users (id, name) VALUES (?, ?)"}, template)
+	as.Equal([][]any{{int64(1), "kyden"}}, params)
+	as.Equal([][]*models.TableInfo{{testutil.WithAccess(models.NewTableInfo("", "users", "", "users"), models.AccessWrite)}}, tableInfos)
+	as.Equal([]bool{false}, pms)
+
+	// REPLACE ... SELECT
+	sql = "REPLACE INTO archive SELECT * FROM orders WHERE id > 100"
+	template, tableInfos, params, op, pms, err = extractor.Extract(sql)
+	as.Nil(err)
+	as.Equal([]models.SQLOpType{models.SQLOperationReplace}, op)
+	as.Equal([]string{"REPLACE INTO archive SELECT * FROM orders WHERE id gt ?"}, template)
+	as.Equal([][]any{{int64(100)}}, params)
+	as.Equal([][]*models.TableInfo{{
+		testutil.WithAccess(models.NewTableInfo("", "archive", "", "archive"), models.AccessWrite),
+		models.NewTableInfo("", "orders", "", "orders"),
+	}}, tableInfos)
+	as.Equal([]bool{false}, pms)
+
+	// REPLACE DELAYED ... SET
+	sql = "REPLACE DELAYED INTO db_1.tb_2 SET a = 1"
+	template, tableInfos, params, op, pms, err = extractor.Extract(sql)
+	as.Nil(err)
+	as.Equal([]models.SQLOpType{models.SQLOperationReplace}, op)
+	as.Equal([]string{"REPLACE DELAYED INTO db_?.tb_? SET a eq ?"}, template)
+	as.Equal([][]any{{int64(1)}}, params)
+	as.Equal(
+		[][]*models.TableInfo{{testutil.WithShards(testutil.WithAccess(models.NewTableInfo("db_1", "tb_2", "db_?", "tb_?"), models.AccessWrite), []string{"1"}, []string{"2"})}},
+		tableInfos,
+	)
+	as.Equal([]bool{false}, pms)
+}
+
+func TestTemplatizeSQL_InsertSet(t *testing.T) {
+	t.Parallel()
+	as := assert.New(t)
+	extractor := NewExtractor()
+
+	// INSERT ... SET
+	sql := "INSERT INTO users SET name = 'kyden', age = 18"
+	template, tableInfos, params, op, pms, err := extractor.Extract(sql)
+	as.Nil(err)
+	as.Equal([]models.SQLOpType{models.SQLOperationInsert}, op)
+	as.Equal([]string{"INSERT INTO users SET name eq ?, age eq ?"}, template)
+	as.Equal([][]any{{"kyden", int64(18)}}, params)
+	as.Equal([][]*models.TableInfo{{testutil.WithAccess(models.NewTableInfo("", "users", "", "users"), models.AccessWrite)}}, tableInfos)
+	as.Equal([]bool{false}, pms)
+
+	// INSERT ... SET ... ON DUPLICATE KEY UPDATE
+	sql = "INSERT INTO users SET id = 1, cnt = 1 ON DUPLICATE KEY UPDATE cnt = cnt + 1"
+	template, tableInfos, params, op, pms, err = extractor.Extract(sql)
+	as.Nil(err)
+	as.Equal([]models.SQLOpType{models.SQLOperationInsert}, op)
+	as.Equal(
+		[]string{"INSERT INTO users SET id eq ?, cnt eq ? ON DUPLICATE KEY UPDATE cnt eq cnt plus ?"},
+		template,
+	)
+	as.Equal([][]any{{int64(1), int64(1), int64(1)}}, params)
+	as.Equal([][]*models.TableInfo{{testutil.WithAccess(models.NewTableInfo("", "users", "", "users"), models.AccessWrite)}}, tableInfos)
+	as.Equal([]bool{false}, pms)
+
+	// INSERT ... SET with param marker
+	sql = "INSERT INTO users SET name = ?"
+	template, tableInfos, params, op, pms, err = extractor.Extract(sql)
+	as.Nil(err)
+	as.Equal([]models.SQLOpType{models.SQLOperationInsert}, op)
+	as.Equal([]string{"INSERT INTO users SET name eq ?"}, template)
+	as.Equal([][]any{{}}, params)
+	as.Equal([][]*models.TableInfo{{testutil.WithAccess(models.NewTableInfo("", "users", "", "users"), models.AccessWrite)}}, tableInfos)
+	as.Equal([]bool{true}, pms)
+}
+
+func TestTemplatizeSQL_InsertPriorityPartition(t *testing.T) {
+	t.Parallel()
+	as := assert.New(t)
+	extractor := NewExtractor()
+
+	// LOW_PRIORITY + PARTITION
+	sql := "INSERT LOW_PRIORITY IGNORE INTO logs PARTITION (p0, p1) (a) VALUES (1)"
+	template, tableInfos, params, op, pms, err := extractor.Extract(sql)
+	as.Nil(err)
+	as.Equal([]models.SQLOpType{models.SQLOperationInsert}, op)
+	as.Equal([]string{"INSERT LOW_PRIORITY IGNORE INTO logs PARTITION (p0, p1) (a) VALUES (?)"}, template)
+	as.Equal([][]any{{int64(1)}}, params)
+	as.Equal([][]*models.TableInfo{{testutil.WithAccess(models.NewTableInfo("", "logs", "", "logs"), models.AccessWrite)}}, tableInfos)
+	as.Equal([]bool{false}, pms)
+
+	// HIGH_PRIORITY
+	sql = "INSERT HIGH_PRIORITY INTO logs (a, b) VALUES (1, 'x'), (2, 'y')"
+	template, tableInfos, params, op, pms, err = extractor.Extract(sql)
+	as.Nil(err)
+	as.Equal([]models.SQLOpType{models.SQLOperationInsert}, op)
+	as.Equal([]string{"INSERT HIGH_PRIORITY INTO logs (a, b) VALUES (?, ?), (?, ?)"}, template)
+	as.Equal([][]any{{int64(1), "x", int64(2), "y"}}, params)
+	as.Equal([][]*models.TableInfo{{testutil.WithAccess(models.NewTableInfo("", "logs", "", "logs"), models.AccessWrite)}}, tableInfos)
+	as.Equal([]bool{false}, pms)
+}
+
+func TestTemplatizeSQL_RenderModeSQL(t *testing.T) {
+	t.Parallel()
+	as := assert.New(t)
+	extractor := NewExtractor(WithRenderMode(RenderModeSQL))
+
+	testCases := []struct {
+		sql      string
+		template string
+		params   []any
+	}{
+		{
+			sql:      "SELECT * FROM users WHERE name = 'kyden' AND age > 18 AND high >= 173 AND weight < 150 and level <= 100 and uuid != 'k' and c <> 2",
+			template: "SELECT * FROM users WHERE name = ? AND age > ? AND high >= ? AND weight < ? AND level <= ? AND uuid != ? AND c != ?",
+			params:   []any{"kyden", int64(18), int64(173), int64(150), int64(100), "k", int64(2)},
+		},
+		{
+			sql:      "SELECT * FROM users WHERE a = 1 OR b <=> NULL XOR c = 2",
+			template: "SELECT * FROM users WHERE a = ? OR b <=> ? XOR c = ?",
+			params:   []any{int64(1), nil, int64(2)},
+		},
+		{
+			sql:      "SELECT a + 1, a - 2, a * 3, a / 4, a DIV 5, a MOD 6, a % 7 FROM t",
+			template: "SELECT a + ?, a - ?, a * ?, a / ?, a DIV ?, a MOD ?, a MOD ? FROM t",
+			params:   []any{int64(1), int64(2), int64(3), int64(4), int64(5), int64(6), int64(7)},
+		},
+		{
+			sql:      "SELECT a & 1, a | 2, a ^ 3, a << 1, a >> 2, ~a, -a, !a, NOT a FROM t",
+			template: "SELECT a & ?, a | ?, a ^ ?, a << ?, a >> ?, ~ a, - a, ! a, NOT a FROM t",
+			params:   []any{int64(1), int64(2), int64(3), int64(1), int64(2)},
+		},
+		{
+			sql:      "UPDATE t SET a = a + 1 WHERE b = 2 AND NOT (c = 3)",
+			template: "UPDATE t SET a = a + ? WHERE b = ? AND NOT (c = ?)",
+			params:   []any{int64(1), int64(2), int64(3)},
+		},
+		{
+			sql:      "SELECT * FROM t WHERE age > ALL (SELECT age FROM u) AND x = ANY (SELECT y FROM v)",
+			template: "SELECT * FROM t WHERE age > ALL((SELECT age FROM u)) AND x = ANY((SELECT y FROM v))",
+			params:   []any{},
+		},
+		{
+			sql:      "INSERT INTO t (a, b) VALUES (1, 2) ON DUPLICATE KEY UPDATE a = VALUES(a) + 1",
+			template: "INSERT INTO t (a, b) VALUES (?, ?) ON DUPLICATE KEY UPDATE a = VALUES(a) + ?",
+			params:   []any{int64(1), int64(2), int64(1)},
+		},
+	}
+
+	for _, tc := range testCases {
+		template, _, params, _, _, err := extractor.Extract(tc.sql)
+		as.Nil(err)
+		as.Equal([]string{tc.template}, template)
+		as.Equal([][]any{tc.params}, params)
+
+		// the templatized SQL round-trips through the parser
+		_, _, err = extractor.parser.Parse(template[0], "", "")
+		as.Nil(err, template[0])
+	}
+}
+
+func TestExtractStatements(t *testing.T) {
+	t.Parallel()
+	as := assert.New(t)
+	psr := NewExtractor()
+
+	sql := "  SELECT * FROM t1 WHERE a = '中文' ;\n\tSELECT * FROM t1 WHERE a = '中文';DELETE FROM t2 WHERE id = ?"
+	stmts, err := psr.ExtractStatements(sql)
+	as.Nil(err)
+	as.Len(stmts, 3)
+
+	as.Equal(0, stmts[0].Index)
+	as.Equal("SELECT * FROM t1 WHERE a = '中文'", stmts[0].Text)
+	as.Equal(2, stmts[0].StartOffset)
+	as.Equal(sql[stmts[0].StartOffset:stmts[0].EndOffset], stmts[0].Text)
+
+	// 相同文本的语句需定位到各自的位置
+	as.Equal(1, stmts[1].Index)
+	as.Equal(stmts[0].Text, stmts[1].Text)
+	as.Greater(stmts[1].StartOffset, stmts[0].EndOffset)
+	as.Equal(sql[stmts[1].StartOffset:stmts[1].EndOffset], stmts[1].Text)
+
+	as.Equal(2, stmts[2].Index)
+	as.Equal("DELETE FROM t2 WHERE id = ?", stmts[2].Text)
+	as.Equal(len(sql), stmts[2].EndOffset)
+	as.Equal("DELETE FROM t2 WHERE id eq ?", stmts[2].TemplatizedSQL)
+	as.Equal(models.SQLOperationDelete, stmts[2].OpType)
+	as.True(stmts[2].HasParamMarker)
+	as.False(stmts[0].HasParamMarker)
+	as.Equal([]any{"中文"}, stmts[0].Params)
+	as.Equal([]*models.TableInfo{testutil.WithAccess(models.NewTableInfo("", "t2", "", "t2"), models.AccessWrite)}, stmts[2].TableInfos)
+	as.Empty(stmts[2].Hash)
+
+	_, err = psr.ExtractStatements("")
+	as.Equal("empty SQL statement", err.Error())
+}
+
+func TestTemplatizeSQL_Options(t *testing.T) {
+	t.Parallel()
+	as := assert.New(t)
+
+	// IN list
+	sql := "SELECT * FROM t WHERE id IN (1, 2, a.id) AND name NOT IN ('a', 'b')"
+	template, _, params, _, _, err := NewExtractor().Extract(sql)
+	as.Nil(err)
+	as.Equal([]string{"SELECT * FROM t WHERE id IN (?, a.id) and name NOT IN (?)"}, template)
+	as.Equal([][]any{{int64(1), int64(2), "a", "b"}}, params)
+
+	template, _, params, _, _, err = NewExtractor(WithINListMode(INListExact)).Extract(sql)
+	as.Nil(err)
+	as.Equal([]string{"SELECT * FROM t WHERE id IN (?, ?, a.id) and name NOT IN (?, ?)"}, template)
+	as.Equal([][]any{{int64(1), int64(2), "a", "b"}}, params)
+
+	template, _, params, _, _, err = NewExtractor(WithINListMode(INListBucket)).Extract(sql)
+	as.Nil(err)
+	as.Equal([]string{"SELECT * FROM t WHERE id IN (?+, a.id) and name NOT IN (?+)"}, template)
+	as.Equal([][]any{{int64(1), int64(2), "a", "b"}}, params)
+
+	// shard pattern
+	sql = "SELECT * FROM db_2024.orders_2024_10 JOIN log20241016 JOIN users_3"
+	template, tableInfos, _, _, _, err := NewExtractor(
+		WithShardPattern(regexp.MustCompile(`(\d+(_\d+)*)$`)),
+	).Extract(sql)
+	as.Nil(err)
+	as.Equal([]string{"SELECT * FROM db_?.orders_? CROSS JOIN log? CROSS JOIN users_?"}, template)
+	as.Equal([]*models.TableInfo{
+		testutil.WithShards(models.NewTableInfo("db_2024", "orders_2024_10", "db_?", "orders_?"), []string{"2024"}, []string{"2024_10"}),
+		testutil.WithShards(models.NewTableInfo("", "log20241016", "", "log?"), nil, []string{"20241016"}),
+		testutil.WithShards(models.NewTableInfo("", "users_3", "", "users_?"), nil, []string{"3"}),
+	}, tableInfos[0])
+
+	template, _, _, _, _, err = NewExtractor(WithShardPattern(regexp.MustCompile(`_\d+$`))).Extract(sql)
+	as.Nil(err)
+	as.Equal([]string{"SELECT * FROM db?.orders_2024? CROSS JOIN log20241016 CROSS JOIN users?"}, template)
+
+	// aggregate literals
+	sql = "SELECT COUNT(1), SUM(IF(a > 5, 1, 0)) FROM t WHERE b = 3"
+	template, _, params, _, _, err = NewExtractor().Extract(sql)
+	as.Nil(err)
+	as.Equal([]string{"SELECT COUNT(1), SUM(IF(a gt 5, 1, 0)) FROM t WHERE b eq ?"}, template)
+	as.Equal([][]any{{int64(3)}}, params)
+
+	template, _, params, _, _, err = NewExtractor(WithInlineAggrLiterals(false)).Extract(sql)
+	as.Nil(err)
+	as.Equal([]string{"SELECT COUNT(?), SUM(IF(a gt ?, ?, ?)) FROM t WHERE b eq ?"}, template)
+	as.Equal([][]any{{int64(1), int64(5), int64(1), int64(0), int64(3)}}, params)
+
+	// SQL mode
+	sql = `SELECT "name" FROM t WHERE a = "x"`
+	template, _, params, _, _, err = NewExtractor().Extract(sql)
+	as.Nil(err)
+	as.Equal([]string{"SELECT ? FROM t WHERE a eq ?"}, template)
+	as.Equal([][]any{{"name", "x"}}, params)
+
+	template, _, params, _, _, err = NewExtractor(WithSQLMode(mysql.ModeANSIQuotes)).Extract(sql)
+	as.Nil(err)
+	as.Equal([]string{"SELECT name FROM t WHERE a eq x"}, template)
+	as.Equal([][]any{{}}, params)
+
+	// charset
+	template, _, params, _, _, err = NewExtractor(WithCharset("utf8mb4", "utf8mb4_bin")).Extract("SELECT * FROM t WHERE a = '中文'")
+	as.Nil(err)
+	as.Equal([]string{"SELECT * FROM t WHERE a eq ?"}, template)
+	as.Equal([][]any{{"中文"}}, params)
+
+	// logger
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	_, _, _, _, _, err = NewExtractor(WithLogger(logger)).Extract("LOCK TABLES t READ")
+	as.Nil(err)
+	as.Contains(buf.String(), "unhandled node")
+	as.Contains(buf.String(), "*ast.LockTablesStmt")
+}
+
+func TestTemplatizeSQL_RowExpr(t *testing.T) {
+	t.Parallel()
+	as := assert.New(t)
+
+	sql := "SELECT * FROM t WHERE (a, b) IN ((1, 2), (3, 4)) AND (c, d) = (5, 'x') AND ROW(e, f) NOT IN ((6, 7))"
+	template, _, params, _, _, err := NewExtractor().Extract(sql)
+	as.Nil(err)
+	as.Equal([]string{"SELECT * FROM t WHERE (a, b) IN (?) and (c, d) eq (?, ?) and (e, f) NOT IN (?)"}, template)
+	as.Equal([][]any{{int64(1), int64(2), int64(3), int64(4), int64(5), "x", int64(6), int64(7)}}, params)
+
+	// 元组列表与标量列表的合并方式一致
+	template, _, params, _, _, err = NewExtractor(WithINListMode(INListExact)).Extract(sql)
+	as.Nil(err)
+	as.Equal(
+		[]string{"SELECT * FROM t WHERE (a, b) IN ((?, ?), (?, ?)) and (c, d) eq (?, ?) and (e, f) NOT IN ((?, ?))"},
+		template,
+	)
+	as.Equal([][]any{{int64(1), int64(2), int64(3), int64(4), int64(5), "x", int64(6), int64(7)}}, params)
+}
+
+func TestTemplatizeSQL_VariableExpr(t *testing.T) {
+	t.Parallel()
+	as := assert.New(t)
+	psr := NewExtractor()
+
+	sql := "SELECT @rank := @rank + 1 AS r, @@sql_mode, @@session.sql_mode, @@GLOBAL.max_connections " +
+		"FROM users, (SELECT @rank := 0) AS init WHERE id > @last_id"
+	stmts, err := psr.ExtractStatements(sql)
+	as.Nil(err)
+	as.Equal(
+		"SELECT @rank := @rank plus ? AS r, @@sql_mode, @@session.sql_mode, @@global.max_connections "+
+			"FROM users CROSS JOIN (SELECT @rank := ?) AS init WHERE id gt @last_id",
+		stmts[0].TemplatizedSQL,
+	)
+	as.Equal([]any{int64(1), int64(0)}, stmts[0].Params)
+	as.Equal([]*models.TableInfo{models.NewTableInfo("", "users", "", "users")}, stmts[0].TableInfos)
+	as.Empty(stmts[0].Warnings)
+}
+
+func TestTemplatizeSQL_MatchAgainst(t *testing.T) {
+	t.Parallel()
+	as := assert.New(t)
+	psr := NewExtractor(WithRenderMode(RenderModeSQL))
+
+	sql := "SELECT * FROM articles a WHERE MATCH(title, a.body) AGAINST('x' IN BOOLEAN MODE) " +
+		"AND MATCH(title) AGAINST ('y' WITH QUERY EXPANSION) AND MATCH(title) AGAINST ('z' IN NATURAL LANGUAGE MODE)"
+	stmts, err := psr.ExtractStatements(sql)
+	as.Nil(err)
+	as.Equal(
+		"SELECT * FROM articles AS a WHERE MATCH (title, a.body) AGAINST (? IN BOOLEAN MODE) "+
+			"AND MATCH (title) AGAINST (? WITH QUERY EXPANSION) AND MATCH (title) AGAINST (?)",
+		stmts[0].TemplatizedSQL,
+	)
+	as.Equal([]any{"x", "y", "z"}, stmts[0].Params)
+	as.Empty(stmts[0].Warnings)
+
+	_, _, err = psr.parser.Parse(stmts[0].TemplatizedSQL, "", "")
+	as.Nil(err)
+}
+
+func TestTemplatizeSQL_SetCollationExpr(t *testing.T) {
+	t.Parallel()
+	as := assert.New(t)
+
+	sql := "SELECT * FROM users WHERE name COLLATE utf8mb4_bin = 'Abc' ORDER BY name COLLATE utf8mb4_general_ci"
+	template, _, params, _, _, err := NewExtractor().Extract(sql)
+	as.Nil(err)
+	as.Equal(
+		[]string{"SELECT * FROM users WHERE name COLLATE utf8mb4_bin eq ? ORDER BY name COLLATE utf8mb4_general_ci"},
+		template,
+	)
+	as.Equal([][]any{{"Abc"}}, params)
+}
+
+func TestTemplatizeSQL_LikeAndByItem(t *testing.T) {
+	t.Parallel()
+	as := assert.New(t)
+	psr := NewExtractor()
+
+	testCases := []struct {
+		sql      string
+		template string
+		params   []any
+	}{
+		{
+			sql:      "SELECT * FROM users WHERE name LIKE 'a%' AND email ILIKE '%@EXAMPLE.COM' AND nick NOT ILIKE 'x%'",
+			template: "SELECT * FROM users WHERE name LIKE ? and email ILIKE ? and nick NOT ILIKE ?",
+			params:   []any{"a%", "%@EXAMPLE.COM", "x%"},
+		},
+		{
+			// 默认转义字符不输出, 其余转义字符保留在模板中
+			sql:      `SELECT * FROM users WHERE name LIKE 'a\_%' ESCAPE '\\' OR name LIKE 'a|_%' ESCAPE '|' OR name ILIKE 'a''_%' ESCAPE ''''`,
+			template: `SELECT * FROM users WHERE name LIKE ? or name LIKE ? ESCAPE '|' or name ILIKE ? ESCAPE '\''`,
+			params:   []any{`a\_%`, "a|_%", "a'_%"},
+		},
+		{
+			// 显式的 ASC 保留在模板中
+			sql:      "SELECT name, COUNT(*) FROM users GROUP BY name ORDER BY name ASC, age, id DESC",
+			template: "SELECT name, COUNT(1) FROM users GROUP BY name ORDER BY name ASC, age, id DESC",
+			params:   []any{},
+		},
+	}
+
+	for _, tc := range testCases {
+		stmts, err := psr.ExtractStatements(tc.sql)
+		as.Nil(err, tc.sql)
+		as.Len(stmts, 1)
+		as.Equal(tc.template, stmts[0].TemplatizedSQL, tc.sql)
+		as.Equal(tc.params, stmts[0].Params, tc.sql)
+	}
+
+	// ORDER BY a 与 ORDER BY a ASC 的模板不同
+	noOrder, err := psr.ExtractStatements("SELECT * FROM users ORDER BY id")
+	as.Nil(err)
+	asc, err := psr.ExtractStatements("SELECT * FROM users ORDER BY id ASC")
+	as.Nil(err)
+	as.NotEqual(noOrder[0].TemplatizedSQL, asc[0].TemplatizedSQL)
+}
+
+func TestTemplatizeSQL_INList(t *testing.T) {
+	t.Parallel()
+	as := assert.New(t)
+
+	testCases := []struct {
+		sql      string
+		mode     INListMode
+		template string
+		params   []any
+	}{
+		{
+			// 列、函数按原顺序输出, 值合并在第一个值的位置
+			sql:      "SELECT * FROM t WHERE id IN (a.id, 5, NOW(), 6)",
+			mode:     INListCollapse,
+			template: "SELECT * FROM t WHERE id IN (a.id, ?, NOW())",
+			params:   []any{int64(5), int64(6)},
+		},
+		{
+			// 仅合并字面值, 占位符与列、函数一样按原顺序输出
+			sql:      "SELECT * FROM t WHERE id IN (a.id, 5, NOW(), ?, 7)",
+			mode:     INListCollapse,
+			template: "SELECT * FROM t WHERE id IN (a.id, ?, NOW(), ?)",
+			params:   []any{int64(5), int64(7)},
+		},
+		{
+			sql:      "SELECT * FROM t WHERE id IN (?, 1, 2, ?)",
+			mode:     INListBucket,
+			template: "SELECT * FROM t WHERE id IN (?, ?+, ?)",
+			params:   []any{int64(1), int64(2)},
+		},
+		{
+			sql:      "SELECT * FROM t WHERE (a, b) IN ((1, 2), (?, 3), (4, 5))",
+			mode:     INListCollapse,
+			template: "SELECT * FROM t WHERE (a, b) IN (?, (?, ?))",
+			params:   []any{int64(1), int64(2), int64(4), int64(5), int64(3)},
+		},
+		{
+			sql:      "SELECT * FROM t WHERE id IN (a.id, 5, NOW(), 6)",
+			mode:     INListExact,
+			template: "SELECT * FROM t WHERE id IN (a.id, ?, NOW(), ?)",
+			params:   []any{int64(5), int64(6)},
+		},
+		{
+			sql:      "SELECT * FROM t WHERE id IN (a.id, 5, NOW(), 6)",
+			mode:     INListBucket,
+			template: "SELECT * FROM t WHERE id IN (a.id, ?+, NOW())",
+			params:   []any{int64(5), int64(6)},
+		},
+		{
+			// 合并后的参数顺序与模板中占位符的顺序一致
+			sql:      "SELECT * FROM t WHERE name IN (1, CONCAT('a', b), 2)",
+			mode:     INListCollapse,
+			template: "SELECT * FROM t WHERE name IN (?, CONCAT(?, b))",
+			params:   []any{int64(1), int64(2), "a"},
+		},
+		{
+			sql:      "SELECT * FROM t WHERE id IN (7)",
+			mode:     INListBucket,
+			template: "SELECT * FROM t WHERE id IN (?)",
+			params:   []any{int64(7)},
+		},
+		{
+			sql:      "SELECT * FROM t WHERE id IN (1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11)",
+			mode:     INListBucket,
+			template: "SELECT * FROM t WHERE id IN (?++)",
+			params: []any{
+				int64(1), int64(2), int64(3), int64(4), int64(5), int64(6),
+				int64(7), int64(8), int64(9), int64(10), int64(11),
+			},
+		},
+		{
+			// 仅由值组成的行可合并, 含列的行按原样输出
+			sql:      "SELECT * FROM t WHERE (a, b) IN ((1, 2), (c, 3), (4, 5))",
+			mode:     INListCollapse,
+			template: "SELECT * FROM t WHERE (a, b) IN (?, (c, ?))",
+			params:   []any{int64(1), int64(2), int64(4), int64(5), int64(3)},
+		},
+	}
+
+	for _, tc := range testCases {
+		stmts, err := NewExtractor(WithINListMode(tc.mode)).ExtractStatements(tc.sql)
+		as.Nil(err, tc.sql)
+		as.Equal(tc.template, stmts[0].TemplatizedSQL, tc.sql)
+		as.Equal(tc.params, stmts[0].Params, tc.sql)
+	}
+
+	// 占位符不合并, 逐项输出且不产生参数
+	stmts, err := NewExtractor().ExtractStatements("SELECT * FROM t WHERE id IN (?, ?, ?)")
+	as.Nil(err)
+	as.Equal("SELECT * FROM t WHERE id IN (?, ?, ?)", stmts[0].TemplatizedSQL)
+	as.Empty(stmts[0].Params)
+	as.True(stmts[0].HasParamMarker)
+
+	for _, size := range []struct {
+		n      int
+		bucket string
+	}{{1, "?"}, {2, "?+"}, {10, "?+"}, {11, "?++"}, {100, "?++"}, {101, "?+++"}, {1000, "?+++"}, {1001, "?++++"}} {
+		as.Equal(size.bucket, inListBucket(size.n), size.n)
+	}
+}
+
+func TestTemplatizeSQL_KeepLiterals(t *testing.T) {
+	t.Parallel()
+	as := assert.New(t)
+
+	testCases := []struct {
+		sql      string
+		keep     LiteralPosition
+		template string
+		params   []any
+	}{
+		{
+			sql:      "SELECT * FROM t WHERE a = 1 LIMIT 20, 10",
+			keep:     LiteralLimit,
+			template: "SELECT * FROM t WHERE a eq ? LIMIT 20, 10",
+			params:   []any{int64(1)},
+		},
+		{
+			sql:      "SELECT * FROM t WHERE d > DATE_SUB(NOW(), INTERVAL 7 DAY) AND e > d + INTERVAL 1 HOUR",
+			keep:     LiteralInterval,
+			template: "SELECT * FROM t WHERE d gt DATE_SUB(NOW(), INTERVAL 7 DAY) and e gt DATE_ADD(d, INTERVAL 1 HOUR)",
+			params:   []any{},
+		},
+		{
+			sql:      "SELECT CASE WHEN a > 0 THEN 'y' ELSE 'n' END FROM t WHERE b = 2",
+			keep:     LiteralCaseWhen,
+			template: "SELECT CASE WHEN a gt 0 THEN 'y' ELSE 'n' END FROM t WHERE b eq ?",
+			params:   []any{int64(2)},
+		},
+		{
+			// 子查询中的 WHERE 仍参数化
+			sql:      "SELECT 1, 'it''s' AS tag, NULL, (SELECT x FROM s WHERE y = 3) FROM t WHERE b = 2",
+			keep:     LiteralSelectList,
+			template: "SELECT 1, 'it\\'s' AS tag, NULL, (SELECT x FROM s WHERE y eq ?) FROM t WHERE b eq ?",
+			params:   []any{int64(3), int64(2)},
+		},
+		{
+			sql:      "SELECT FLOOR(age / 10), COUNT(1) FROM t GROUP BY FLOOR(age / 10) HAVING COUNT(1) > 5",
+			keep:     LiteralGroupBy,
+			template: "SELECT FLOOR(age div ?), COUNT(1) FROM t GROUP BY FLOOR(age div 10) HAVING COUNT(1) gt ?",
+			params:   []any{int64(10), int64(5)},
+		},
+		{
+			// 保留的字面值为类型不变的合法 SQL
+			sql:      "SELECT 1e-10, 1e20, 2.5e0, 0x1F, b'101', X'0a', 1.50 FROM t WHERE b = 2",
+			keep:     LiteralSelectList,
+			template: "SELECT 1e-10, 1e+20, 2.5e0, X'1f', X'05', X'0a', 1.50 FROM t WHERE b eq ?",
+			params:   []any{int64(2)},
+		},
+		{
+			// 与 Rehydrate 相同的转义: NUL、换行及有转义序列的控制字符
+			sql:      "SELECT 'a\\0b', 'c\\nd\\r', '\\t\\Z', 'e\x01' FROM t WHERE b = 2",
+			keep:     LiteralSelectList,
+			template: "SELECT 'a\\0b', 'c\\nd\\r', '\\t\\Z', 'e\x01' FROM t WHERE b eq ?",
+			params:   []any{int64(2)},
+		},
+		{
+			sql:      "SELECT COUNT(1) FROM t GROUP BY a + 1e3, a & 0x0F, a | b'11'",
+			keep:     LiteralGroupBy,
+			template: "SELECT COUNT(1) FROM t GROUP BY a plus 1000e0, a bitand X'0f', a bitor X'03'",
+			params:   []any{},
+		},
+		{
+			// 聚合函数中的子查询不受聚合函数的配置影响
+			sql:      "SELECT SUM((SELECT x FROM s WHERE y = 3)), COUNT(1) FROM t LIMIT 5",
+			keep:     LiteralLimit,
+			template: "SELECT SUM((SELECT x FROM s WHERE y eq ?)), COUNT(1) FROM t LIMIT 5",
+			params:   []any{int64(3)},
+		},
+	}
+
+	for _, tc := range testCases {
+		stmts, err := NewExtractor(WithKeepLiterals(tc.keep, true)).ExtractStatements(tc.sql)
+		as.Nil(err, tc.sql)
+		as.Equal(tc.template, stmts[0].TemplatizedSQL, tc.sql)
+		as.Equal(tc.params, stmts[0].Params, tc.sql)
+
+		// 默认参数化
+		stmts, err = NewExtractor().ExtractStatements(tc.sql)
+		as.Nil(err, tc.sql)
+		as.NotEqual(tc.template, stmts[0].TemplatizedSQL, tc.sql)
+	}
+
+	// 严格模式下保留十六进制及位值字面值不产生警告
+	stmts, err := NewExtractor(WithKeepLiterals(LiteralSelectList, true), WithStrict(true)).
+		ExtractStatements("SELECT 0x1F, b'1', 1e-10 FROM t")
+	as.Nil(err)
+	as.Equal("SELECT X'1f', X'01', 1e-10 FROM t", stmts[0].TemplatizedSQL)
+	as.Empty(stmts[0].Warnings)
+
+	// 取消默认保留的聚合函数字面值
+	stmts, err = NewExtractor(
+		WithKeepLiterals(LiteralAggrFunc, false),
+		WithKeepLiterals(LiteralLimit|LiteralSelectList, true),
+	).ExtractStatements("SELECT 'a', COUNT(1) FROM t GROUP BY a HAVING COUNT(1) > 2 LIMIT 10")
+	as.Nil(err)
+	as.Equal("SELECT 'a', COUNT(1) FROM t GROUP BY a HAVING COUNT(?) gt ? LIMIT 10", stmts[0].TemplatizedSQL)
+	as.Equal([]any{int64(1), int64(2)}, stmts[0].Params)
+}
+
+func TestExtractStatements_Aliases(t *testing.T) {
+	t.Parallel()
+	as := assert.New(t)
+	psr := NewExtractor()
+
+	// 物理表别名, 自连接
+	stmts, err := psr.ExtractStatements(
+		"SELECT o.uid, p.uid FROM db_1.orders_2 AS o JOIN orders_2 p ON o.pid = p.id JOIN users u ON u.id = o.uid")
+	as.Nil(err)
+	orders, users := stmts[0].TableInfos[0], stmts[0].TableInfos[2]
+	as.Equal([]models.Alias{
+		{Name: "o", Kind: models.AliasTable, Tables: []*models.TableInfo{orders}},
+		{Name: "p", Kind: models.AliasTable, Tables: []*models.TableInfo{stmts[0].TableInfos[1]}},
+		{Name: "u", Kind: models.AliasTable, Tables: []*models.TableInfo{users}},
+	}, stmts[0].Aliases)
+	as.Equal([]*models.TableInfo{orders}, stmts[0].ResolveAlias("o"))
+	as.Equal([]*models.TableInfo{users}, stmts[0].ResolveAlias("U"))
+	as.Equal([]*models.TableInfo{orders}, stmts[0].ResolveAlias("db_1.orders_2"))
+	as.Nil(stmts[0].ResolveAlias("x"))
+
+	// 派生表别名指向其读取的表
+	stmts, err = psr.ExtractStatements(
+		"SELECT d.uid FROM (SELECT o.uid FROM orders o JOIN users u ON o.uid = u.id) AS d WHERE d.uid > 1")
+	as.Nil(err)
+	as.Len(stmts[0].Aliases, 3)
+	as.Equal(models.Alias{Name: "d", Kind: models.AliasDerived, Tables: stmts[0].TableInfos}, stmts[0].Aliases[2])
+	as.Equal(stmts[0].TableInfos, stmts[0].ResolveAlias("d"))
+
+	// CTE 名称及其引用的别名
+	stmts, err = psr.ExtractStatements(
+		"WITH r AS (SELECT uid FROM orders), s AS (SELECT uid FROM r JOIN users ON r.uid = users.id) " +
+			"SELECT x.uid FROM s AS x JOIN r")
+	as.Nil(err)
+	orders, users = stmts[0].TableInfos[0], stmts[0].TableInfos[1]
+	as.Equal([]models.Alias{
+		{Name: "r", Kind: models.AliasCTE, Tables: []*models.TableInfo{orders}},
+		{Name: "s", Kind: models.AliasCTE, Tables: []*models.TableInfo{orders, users}},
+		{Name: "x", Kind: models.AliasCTE, Tables: []*models.TableInfo{orders, users}},
+	}, stmts[0].Aliases)
+	as.Equal([]*models.TableInfo{orders}, stmts[0].ResolveAlias("r"))
+	as.Equal([]*models.TableInfo{orders, users}, stmts[0].ResolveAlias("x"))
+
+	// 不同查询块中的同名别名
+	stmts, err = psr.ExtractStatements("SELECT t.a FROM orders t WHERE t.uid IN (SELECT t.id FROM users t)")
+	as.Nil(err)
+	as.Equal(stmts[0].TableInfos, stmts[0].ResolveAlias("t"))
+
+	// UPDATE 及 DELETE 的多表别名
+	stmts, err = psr.ExtractStatements("UPDATE orders o JOIN users u ON o.uid = u.id SET o.name = u.name")
+	as.Nil(err)
+	as.Equal([]*models.TableInfo{stmts[0].TableInfos[0]}, stmts[0].ResolveAlias("o"))
+
+	stmts, err = psr.ExtractStatements("DELETE o FROM orders AS o JOIN users AS u ON o.uid = u.id WHERE u.id = 1")
+	as.Nil(err)
+	as.Equal([]*models.TableInfo{stmts[0].TableInfos[0]}, stmts[0].ResolveAlias("o"))
+	as.Equal("users", stmts[0].ResolveAlias("u")[0].TableName())
+}
+
+func TestExtractStatements_Access(t *testing.T) {
+	t.Parallel()
+	as := assert.New(t)
+	psr := NewExtractor()
+
+	testCases := []struct {
+		sql    string
+		access map[string]models.AccessMode
+	}{
+		{
+			sql:    "INSERT INTO archive SELECT * FROM orders",
+			access: map[string]models.AccessMode{"archive": models.AccessWrite, "orders": models.AccessRead},
+		},
+		{
+			// 同一表既被读取又被修改
+			sql:    "INSERT INTO t SELECT * FROM t WHERE id > 1",
+			access: map[string]models.AccessMode{"t": models.AccessReadWrite},
+		},
+		{
+			sql:    "UPDATE a JOIN b ON a.id = b.id SET a.x = b.y",
+			access: map[string]models.AccessMode{"a": models.AccessWrite, "b": models.AccessRead},
+		},
+		{
+			sql:    "UPDATE a AS p JOIN b AS q ON p.id = q.id SET q.x = p.y",
+			access: map[string]models.AccessMode{"a": models.AccessRead, "b": models.AccessWrite},
+		},
+		{
+			// 未限定表名的列无法确定所属表
+			sql:    "UPDATE a JOIN b ON a.id = b.id SET x = 1",
+			access: map[string]models.AccessMode{"a": models.AccessWrite, "b": models.AccessWrite},
+		},
+		{
+			sql:    "WITH r AS (SELECT * FROM o) UPDATE t JOIN r ON t.id = r.id SET t.a = (SELECT MAX(b) FROM u)",
+			access: map[string]models.AccessMode{"o": models.AccessRead, "t": models.AccessWrite, "u": models.AccessRead},
+		},
+		{
+			sql:    "DELETE a FROM a JOIN b ON a.id = b.id",
+			access: map[string]models.AccessMode{"a": models.AccessWrite, "b": models.AccessRead},
+		},
+		{
+			sql:    "DELETE FROM a USING a JOIN b ON a.id = b.id",
+			access: map[string]models.AccessMode{"a": models.AccessWrite, "b": models.AccessRead},
+		},
+		{
+			// 同名表按库名区分
+			sql:    "DELETE db1.t FROM db1.t JOIN db2.t ON db1.t.id = db2.t.id",
+			access: map[string]models.AccessMode{"db1.t": models.AccessWrite, "db2.t": models.AccessRead},
+		},
+		{
+			sql:    "UPDATE db1.t JOIN db2.t ON db1.t.id = db2.t.id SET db1.t.a = 1",
+			access: map[string]models.AccessMode{"db1.t": models.AccessWrite, "db2.t": models.AccessRead},
+		},
+		{
+			sql:    "UPDATE db1.t AS x JOIN db2.t AS y ON x.id = y.id SET y.a = 1",
+			access: map[string]models.AccessMode{"db1.t": models.AccessRead, "db2.t": models.AccessWrite},
+		},
+		{
+			sql:    "DELETE FROM t WHERE id IN (SELECT id FROM u)",
+			access: map[string]models.AccessMode{"t": models.AccessWrite, "u": models.AccessRead},
+		},
+		{
+			sql:    "CREATE TABLE t2 AS SELECT * FROM t1",
+			access: map[string]models.AccessMode{"t2": models.AccessDDL, "t1": models.AccessRead},
+		},
+		{
+			sql:    "RENAME TABLE a TO b",
+			access: map[string]models.AccessMode{"a": models.AccessDDL, "b": models.AccessDDL},
+		},
+		{
+			sql:    "CREATE TABLE t2 LIKE t1",
+			access: map[string]models.AccessMode{"t2": models.AccessDDL, "t1": models.AccessRead},
+		},
+		{
+			// 外键引用的表只被读取
+			sql:    "ALTER TABLE c ADD CONSTRAINT fk FOREIGN KEY (pid) REFERENCES p (id)",
+			access: map[string]models.AccessMode{"c": models.AccessDDL, "p": models.AccessRead},
+		},
+		{
+			sql:    "SHOW INDEX FROM t",
+			access: map[string]models.AccessMode{"t": models.AccessMetadata},
+		},
+		{
+			// 仅 EXPLAIN ANALYZE 会执行语句
+			sql:    "EXPLAIN UPDATE t SET a = 1",
+			access: map[string]models.AccessMode{"t": models.AccessMetadata},
+		},
+		{
+			sql:    "EXPLAIN ANALYZE UPDATE t SET a = 1",
+			access: map[string]models.AccessMode{"t": models.AccessWrite},
+		},
+	}
+
+	for _, tc := range testCases {
+		stmts, err := psr.ExtractStatements(tc.sql)
+		as.Nil(err, tc.sql)
+
+		access := make(map[string]models.AccessMode, len(stmts[0].TableInfos))
+		for _, ti := range stmts[0].TableInfos {
+			access[tableKey(ti)] = ti.Access()
+		}
+		as.Equal(tc.access, access, tc.sql)
+	}
+}
+
+func TestExtractStatements_Columns(t *testing.T) {
+	t.Parallel()
+	as := assert.New(t)
+	psr := NewExtractor()
+
+	// column 返回列的简要描述: 限定名.列名 子句 访问方式 所属表
+	column := func(c models.Column) string {
+		tables := lo.Map(c.Tables, func(t *models.TableInfo, _ int) string { return tableKey(t) })
+		return fmt.Sprintf("%s.%s %s %s %v", c.Qualifier(), c.Name, c.Clause, c.Access, tables)
+	}
+
+	testCases := []struct {
+		sql     string
+		columns []string
+	}{
+		{
+			sql: "SELECT o.uid, name, COUNT(*) AS cnt FROM orders AS o JOIN users u ON o.uid = u.id " +
+				"WHERE u.age > 18 GROUP BY o.uid, name ORDER BY cnt DESC",
+			columns: []string{
+				"o.uid SELECT READ [orders]",
+				// 未限定表名的列可能属于任一表
+				".name SELECT READ [orders users]",
+				"o.uid ON READ [orders]",
+				"u.id ON READ [users]",
+				"u.age WHERE READ [users]",
+				"o.uid GROUP BY READ [orders]",
+				".name GROUP BY READ [orders users]",
+			},
+		},
+		{
+			sql: "SELECT * FROM t WHERE id IN (SELECT tid FROM u WHERE u.x = t.y)",
+			columns: []string{
+				".* SELECT READ [t]",
+				".id WHERE READ [t]",
+				".tid SELECT READ [u]",
+				"u.x WHERE READ [u]",
+				"t.y WHERE READ [t]",
+			},
+		},
+		{
+			// 子查询没有表时, 未限定列属于外层查询块的表
+			sql:     "SELECT a FROM t WHERE EXISTS (SELECT 1 WHERE b > 1)",
+			columns: []string{".a SELECT READ [t]", ".b WHERE READ [t]"},
+		},
+		{
+			sql:     "SELECT t.*, db.u.c FROM t, db.u",
+			columns: []string{"t.* SELECT READ [t]", "db.u.c SELECT READ [db.u]"},
+		},
+		{
+			sql: "INSERT INTO t (a, b) VALUES (1, 2) ON DUPLICATE KEY UPDATE b = VALUES(b)",
+			columns: []string{
+				".a INTO WRITE [t]",
+				".b INTO WRITE [t]",
+				".b ON DUPLICATE KEY UPDATE WRITE [t]",
+				".b ON DUPLICATE KEY UPDATE READ [t]",
+			},
+		},
+		{
+			sql:     "INSERT INTO t SET a = 1",
+			columns: []string{".a SET WRITE [t]"},
+		},
+		{
+			sql: "INSERT INTO archive (id) SELECT id FROM orders WHERE created < '2020-01-01'",
+			columns: []string{
+				".id INTO WRITE [archive]",
+				".id SELECT READ [orders]",
+				".created WHERE READ [orders]",
+			},
+		},
+		{
+			sql: "UPDATE a JOIN b ON a.id = b.id SET a.x = b.y, z = 1 WHERE b.k = 2",
+			columns: []string{
+				"a.id ON READ [a]",
+				"b.id ON READ [b]",
+				"a.x SET WRITE [a]",
+				"b.y SET READ [b]",
+				".z SET WRITE [a b]",
+				"b.k WHERE READ [b]",
+			},
+		},
+		{
+			sql:     "DELETE FROM t WHERE id = 1 ORDER BY created LIMIT 1",
+			columns: []string{".id WHERE READ [t]", ".created ORDER BY READ [t]"},
+		},
+		{
+			sql: "DELETE o FROM orders AS o JOIN users u ON o.uid = u.id WHERE u.banned = 1",
+			columns: []string{
+				"o.uid ON READ [orders]",
+				"u.id ON READ [users]",
+				"u.banned WHERE READ [users]",
+			},
+		},
+		{
+			sql: "WITH r AS (SELECT id FROM o) SELECT r.id, x FROM r",
+			columns: []string{
+				".id SELECT READ [o]",
+				"r.id SELECT READ [o]",
+				".x SELECT READ [o]",
+			},
+		},
+		{
+			sql:     "SELECT d.k FROM (SELECT k FROM t) AS d",
+			columns: []string{"d.k SELECT READ [t]", ".k SELECT READ [t]"},
+		},
+		{
+			// 集合操作的 ORDER BY 引用结果列
+			sql:     "SELECT a FROM t1 UNION SELECT b FROM t2 ORDER BY a",
+			columns: []string{".a SELECT READ [t1]", ".b SELECT READ [t2]", ".a ORDER BY READ []"},
+		},
+		{
+			sql:     "SELECT a AS a FROM t ORDER BY a",
+			columns: []string{".a SELECT READ [t]", ".a ORDER BY READ [t]"},
+		},
+		{
+			sql:     "SELECT 1",
+			columns: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		stmts, err := psr.ExtractStatements(tc.sql)
+		as.Nil(err, tc.sql)
+
+		columns := lo.Map(stmts[0].Columns, func(c models.Column, _ int) string { return column(c) })
+		as.Equal(tc.columns, lo.Ternary(len(columns) == 0, nil, columns), tc.sql)
+	}
+
+	// 所属表与 TableInfos 中的元素相同
+	stmts, err := psr.ExtractStatements("SELECT name FROM users WHERE id = 1")
+	as.Nil(err)
+	as.Same(stmts[0].TableInfos[0], stmts[0].Columns[0].Tables[0])
+	as.Same(stmts[0].TableInfos[0], stmts[0].Columns[1].Tables[0])
+}
+
+func TestExtractStatements_Predicates(t *testing.T) {
+	t.Parallel()
+	as := assert.New(t)
+	psr := NewExtractor()
+
+	// predicate 返回谓词的简要描述: 限定名.列名 运算符 参数下标 逻辑上下文
+	predicate := func(p models.Predicate) string {
+		return fmt.Sprintf("%s.%s %s %v %v", p.Column.Qualifier(), p.Column.Name, p.Operator, p.ParamIndexes, p.Context)
+	}
+
+	testCases := []struct {
+		sql        string
+		predicates []string
+	}{
+		{
+			sql: "SELECT * FROM t WHERE uid = 42 AND (status IN (1, 2, 3) OR name LIKE 'a%') " +
+				"AND NOT created BETWEEN '2020-01-01' AND '2021-01-01'",
+			predicates: []string{
+				".uid = [0] [AND]",
+				".status IN [1 2 3] [AND OR]",
+				".name LIKE [4] [AND OR]",
+				".created BETWEEN [5 6] [AND NOT]",
+			},
+		},
+		{
+			// 值在左侧时交换比较方向, 列与列的比较不是谓词, 子查询的逻辑上下文独立
+			sql: "SELECT * FROM t WHERE 5 < a AND b = c AND d = ? AND e IN (SELECT x FROM u WHERE u.y <> 3)",
+			predicates: []string{
+				".a > [0] [AND]",
+				".d = [] [AND]",
+				"u.y != [1] []",
+			},
+		},
+		{
+			// 包含列的 IN 列表不是谓词
+			sql:        "SELECT * FROM t WHERE a IN (1, b) AND c NOT LIKE ? AND d NOT IN (4) OR e NOT BETWEEN 5 AND 6",
+			predicates: []string{".c NOT LIKE [] [OR AND]", ".d NOT IN [1] [OR AND]", ".e NOT BETWEEN [2 3] [OR]"},
+		},
+		{
+			sql:        "UPDATE t SET a = 1 WHERE id = 2",
+			predicates: []string{".id = [1] []"},
+		},
+		{
+			// 负数字面值的负号保留在模板中, 参数为其绝对值
+			sql:        "SELECT * FROM t WHERE a = -5 AND b BETWEEN -2.5 AND 3 AND c IN (-1, 2) AND d < -e",
+			predicates: []string{".a = [0] [AND]", ".b BETWEEN [1 2] [AND]", ".c IN [3 4] [AND]"},
+		},
+		{
+			sql:        "SELECT * FROM a JOIN b ON a.id = b.id AND b.kind = 'x' WHERE a.uid = 7",
+			predicates: []string{"b.kind = [0] [AND]", "a.uid = [1] []"},
+		},
+		{
+			sql:        "SELECT 1",
+			predicates: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		stmts, err := psr.ExtractStatements(tc.sql)
+		as.Nil(err, tc.sql)
+
+		predicates := lo.Map(stmts[0].Predicates, func(p models.Predicate, _ int) string { return predicate(p) })
+		as.Equal(tc.predicates, lo.Ternary(len(predicates) == 0, nil, predicates), tc.sql)
+	}
+
+	// 谓词的列与 Columns 中的列相同, 并解析所属表
+	stmts, err := psr.ExtractStatements("SELECT name FROM users AS u WHERE u.uid = 42")
+	as.Nil(err)
+	as.Len(stmts[0].Predicates, 1)
+	pred := stmts[0].Predicates[0]
+	as.Equal(stmts[0].Columns[1], pred.Column)
+	as.Equal(models.ClauseWhere, pred.Column.Clause)
+	as.Same(stmts[0].TableInfos[0], pred.Column.Tables[0])
+	as.Equal([]any{int64(42)}, stmts[0].PredicateParams(&pred))
+
+	stmts, err = psr.ExtractStatements("SELECT * FROM t WHERE a = -5")
+	as.Nil(err)
+	as.Equal("SELECT * FROM t WHERE a eq minus ?", stmts[0].TemplatizedSQL)
+	as.Equal([]any{int64(5)}, stmts[0].PredicateParams(&stmts[0].Predicates[0]))
+
+	// SELECT 列表别名不计入 Columns, 也不作为谓词的列, 其参数不关联列
+	stmts, err = psr.ExtractStatements("SELECT k, COUNT(*) AS cnt FROM t GROUP BY k HAVING cnt > 5")
+	as.Nil(err)
+	as.Equal([]string{"k", "k"}, lo.Map(stmts[0].Columns, func(c models.Column, _ int) string { return c.Name }))
+	as.Empty(stmts[0].Predicates)
+	as.Len(stmts[0].ParamInfos, 1)
+	as.Nil(stmts[0].ParamInfos[0].Column)
+
+	// 别名与表的列混用时, 只保留表的列上的谓词, 且谓词的列均在 Columns 中
+	stmts, err = psr.ExtractStatements("SELECT a, COUNT(*) cnt FROM t GROUP BY a HAVING cnt > 1 AND a < 3")
+	as.Nil(err)
+	as.Equal([]string{".a < [1] [AND]"}, lo.Map(stmts[0].Predicates, func(p models.Predicate, _ int) string {
+		return predicate(p)
+	}))
+	as.Contains(stmts[0].Columns, stmts[0].Predicates[0].Column)
+	as.Same(stmts[0].TableInfos[0], stmts[0].Predicates[0].Column.Tables[0])
+}
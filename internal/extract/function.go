@@ -0,0 +1,276 @@
+package extract
+
+import (
+	"strings"
+
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/parser/format"
+	"github.com/pingcap/tidb/pkg/parser/mysql"
+	"github.com/pingcap/tidb/pkg/parser/test_driver"
+
+	"github.com/kydenul/sql-extractor/internal/literal"
+)
+
+// handleFuncCastExpr 处理类型转换表达式
+//
+// e.g. CAST(a AS CHAR(10)), CONVERT(a, SIGNED), BINARY a
+func (v *ExtractVisitor) handleFuncCastExpr(node *ast.FuncCastExpr) {
+	switch node.FunctionType {
+	case ast.CastFunction:
+		v.builder.WriteString("CAST(")
+		node.Expr.Accept(v)
+		v.builder.WriteString(" AS ")
+		v.appendCastType(node)
+		v.builder.WriteString(")")
+
+	case ast.CastConvertFunction:
+		v.builder.WriteString("CONVERT(")
+		node.Expr.Accept(v)
+		v.builder.WriteString(", ")
+		v.appendCastType(node)
+		v.builder.WriteString(")")
+
+	case ast.CastBinaryOperator:
+		v.builder.WriteString("BINARY ")
+		node.Expr.Accept(v)
+	}
+}
+
+// appendCastType 添加类型转换的目标类型到 SQL 字符串, e.g. CHAR(10) CHARSET utf8mb4, DECIMAL(10,2)
+func (v *ExtractVisitor) appendCastType(node *ast.FuncCastExpr) {
+	node.Tp.RestoreAsCastType(format.NewRestoreCtx(restoreFlags, v.builder), node.ExplicitCharSet)
+
+	// 还原时省略了默认字符集 utf8mb4, 而未指定字符集时使用的是连接的字符集, 显式指定时需保留
+	isChar := node.Tp.GetType() == mysql.TypeString || node.Tp.GetType() == mysql.TypeVarString
+	if isChar && node.ExplicitCharSet && node.Tp.GetCharset() == mysql.DefaultCharset {
+		v.builder.WriteString(" CHARSET ")
+		v.builder.WriteString(strings.ToUpper(mysql.DefaultCharset))
+	}
+}
+
+// handleSpecialFuncCall 处理参数不以逗号分隔的特殊函数, 返回是否已处理
+//
+// e.g. CONVERT(a USING utf8mb4), TRIM(LEADING ? FROM a), EXTRACT(YEAR FROM a),
+// DATE_ADD(a, INTERVAL ? DAY), POSITION(? IN a), ? MEMBER OF (a)
+//
+//nolint:cyclop
+func (v *ExtractVisitor) handleSpecialFuncCall(node *ast.FuncCallExpr) bool {
+	switch node.FnName.L {
+	// DATE '2025-01-01' 等时间字面值的关键字后只能是字符串常量, 输出为等价的 CAST(? AS DATE)
+	case ast.DateLiteral, ast.TimeLiteral, ast.TimestampLiteral:
+		v.builder.WriteString("CAST(")
+		node.Args[0].Accept(v)
+		v.builder.WriteString(" AS ")
+		v.builder.WriteString(temporalLiteralTypes[node.FnName.L])
+		v.builder.WriteString(")")
+
+	case ast.JSONMemberOf:
+		node.Args[0].Accept(v)
+		v.builder.WriteString(" MEMBER OF (")
+		node.Args[1].Accept(v)
+		v.builder.WriteString(")")
+
+	// 字符集名称保留在模板中
+	case ast.Convert:
+		v.builder.WriteString(node.FnName.O)
+		v.builder.WriteString("(")
+		node.Args[0].Accept(v)
+		v.builder.WriteString(" USING ")
+		v.builder.WriteString(valueString(node.Args[1]))
+		v.builder.WriteString(")")
+
+	case ast.DateAdd, ast.DateSub, ast.AddDate, ast.SubDate:
+		v.builder.WriteString(node.FnName.O)
+		v.builder.WriteString("(")
+		node.Args[0].Accept(v)
+		v.builder.WriteString(", INTERVAL ")
+		v.appendInterval(node.Args[1])
+		v.builder.WriteString(" ")
+		v.builder.WriteString(timeUnit(node.Args[2]))
+		v.builder.WriteString(")")
+
+	case ast.Extract:
+		v.builder.WriteString(node.FnName.O)
+		v.builder.WriteString("(")
+		v.builder.WriteString(timeUnit(node.Args[0]))
+		v.builder.WriteString(" FROM ")
+		node.Args[1].Accept(v)
+		v.builder.WriteString(")")
+
+	// 时间单位为首个参数, e.g. TIMESTAMPDIFF(DAY, a, b)
+	case ast.TimestampDiff, ast.TimestampAdd:
+		v.builder.WriteString(node.FnName.O)
+		v.builder.WriteString("(")
+		v.builder.WriteString(timeUnit(node.Args[0]))
+		for _, arg := range node.Args[1:] {
+			v.builder.WriteString(", ")
+			arg.Accept(v)
+		}
+		v.builder.WriteString(")")
+
+	case ast.Position:
+		v.builder.WriteString(node.FnName.O)
+		v.builder.WriteString("(")
+		node.Args[0].Accept(v)
+		v.builder.WriteString(" IN ")
+		node.Args[1].Accept(v)
+		v.builder.WriteString(")")
+
+	case ast.Trim:
+		v.handleTrim(node)
+
+	case ast.CharFunc:
+		v.handleCharFunc(node)
+
+	// WEIGHT_STRING(a AS CHAR(4)), 长度只能是整数常量, 保留在模板中
+	case ast.WeightString:
+		v.builder.WriteString(node.FnName.O)
+		v.builder.WriteString("(")
+		node.Args[0].Accept(v)
+		if len(node.Args) == 3 {
+			v.builder.WriteString(" AS ")
+			v.builder.WriteString(valueString(node.Args[1]))
+			v.builder.WriteString("(")
+			v.restore(node.Args[2])
+			v.builder.WriteString(")")
+		}
+		v.builder.WriteString(")")
+
+	default:
+		return false
+	}
+
+	return true
+}
+
+// appendInterval 添加 INTERVAL 的间隔值到 SQL 字符串
+func (v *ExtractVisitor) appendInterval(expr ast.ExprNode) {
+	defer v.enterLiteralCtx(LiteralInterval)()
+	expr.Accept(v)
+}
+
+// temporalLiteralTypes 时间字面值对应的类型
+var temporalLiteralTypes = map[string]string{
+	ast.DateLiteral:      "DATE",
+	ast.TimeLiteral:      "TIME",
+	ast.TimestampLiteral: "DATETIME",
+}
+
+// handleTrim 处理 TRIM 函数, 参数依次为: 字符串, 要移除的字符串, 方向
+//
+// e.g. TRIM(a), TRIM(? FROM a), TRIM(LEADING ? FROM a), TRIM(BOTH FROM a)
+func (v *ExtractVisitor) handleTrim(node *ast.FuncCallExpr) {
+	v.builder.WriteString(node.FnName.O)
+	v.builder.WriteString("(")
+
+	if len(node.Args) == 3 {
+		if dir, ok := node.Args[2].(*ast.TrimDirectionExpr); ok && dir.Direction.String() != "" {
+			v.builder.WriteString(dir.Direction.String())
+			v.builder.WriteString(" ")
+		}
+	}
+
+	if len(node.Args) >= 2 {
+		// TRIM(LEADING FROM a) 未指定要移除的字符串时为 NULL 值
+		if val, ok := node.Args[1].(*test_driver.ValueExpr); !ok || val.GetValue() != nil {
+			node.Args[1].Accept(v)
+			v.builder.WriteString(" ")
+		}
+		v.builder.WriteString("FROM ")
+	}
+
+	node.Args[0].Accept(v)
+	v.builder.WriteString(")")
+}
+
+// handleCharFunc 处理 CHAR 函数, 最后一个参数为字符集 (未指定时为 NULL 值)
+//
+// e.g. CHAR(?, ?), CHAR(?, ? USING utf8mb4)
+func (v *ExtractVisitor) handleCharFunc(node *ast.FuncCallExpr) {
+	v.builder.WriteString("CHAR(")
+
+	last := len(node.Args) - 1
+	for idx := range last {
+		if idx > 0 {
+			v.builder.WriteString(", ")
+		}
+
+		node.Args[idx].Accept(v)
+	}
+
+	if charset := valueString(node.Args[last]); charset != "" {
+		v.builder.WriteString(" USING ")
+		v.builder.WriteString(charset)
+	}
+
+	v.builder.WriteString(")")
+}
+
+// handleGetFormatSelectorExpr 处理 GET_FORMAT 函数的日期类型, e.g. GET_FORMAT(DATE, ?)
+func (v *ExtractVisitor) handleGetFormatSelectorExpr(node *ast.GetFormatSelectorExpr) {
+	v.builder.WriteString(node.Selector.String())
+}
+
+// jsonPathArgs JSON 函数中路径参数的位置, 返回第 idx 个参数是否为路径
+var jsonPathArgs = map[string]func(idx int) bool{
+	ast.JSONExtract:      func(idx int) bool { return idx >= 1 },
+	ast.JSONRemove:       func(idx int) bool { return idx >= 1 },
+	ast.JSONKeys:         func(idx int) bool { return idx == 1 },
+	ast.JSONLength:       func(idx int) bool { return idx == 1 },
+	ast.JSONContains:     func(idx int) bool { return idx == 2 },
+	ast.JSONContainsPath: func(idx int) bool { return idx >= 2 },
+	ast.JSONSearch:       func(idx int) bool { return idx >= 4 },
+	ast.JSONSet:          func(idx int) bool { return idx%2 == 1 },
+	ast.JSONInsert:       func(idx int) bool { return idx%2 == 1 },
+	ast.JSONReplace:      func(idx int) bool { return idx%2 == 1 },
+	ast.JSONArrayAppend:  func(idx int) bool { return idx%2 == 1 },
+	ast.JSONArrayInsert:  func(idx int) bool { return idx%2 == 1 },
+}
+
+// keepJSONPath 若第 idx 个参数为 JSON 路径字面值且配置为保留路径, 则直接输出该字面值, 返回是否已处理
+//
+// `doc->'$.a'` 与 `doc->>'$.a'` 解析为 json_extract(doc, '$.a') 与 json_unquote(json_extract(doc, '$.a')),
+// 同样适用
+func (v *ExtractVisitor) keepJSONPath(node *ast.FuncCallExpr, idx int) bool {
+	if v.conf().jsonPathMode != JSONPathKeep {
+		return false
+	}
+
+	isPath, ok := jsonPathArgs[node.FnName.L]
+	if !ok || !isPath(idx) {
+		return false
+	}
+
+	val, ok := node.Args[idx].(*test_driver.ValueExpr)
+	if !ok {
+		return false
+	}
+	path, isString := val.GetValue().(string)
+	if !isString {
+		return false
+	}
+
+	v.builder.WriteString(literal.Quote(path))
+	v.markKeptLiteral(val)
+	return true
+}
+
+// valueString 返回字符串值表达式的值, 非字符串时返回空字符串
+func valueString(expr ast.ExprNode) string {
+	if val, ok := expr.(*test_driver.ValueExpr); ok {
+		if str, ok := val.GetValue().(string); ok {
+			return str
+		}
+	}
+
+	return ""
+}
+
+// timeUnit 返回时间单位表达式的单位, e.g. DAY, YEAR_MONTH
+func timeUnit(expr ast.ExprNode) string {
+	if unit, ok := expr.(*ast.TimeUnitExpr); ok {
+		return unit.Unit.String()
+	}
+
+	return ""
+}
@@ -0,0 +1,121 @@
+package extract
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplatizeSQL_SpecialFunc(t *testing.T) {
+	t.Parallel()
+	as := assert.New(t)
+	psr := NewExtractor()
+
+	testCases := []struct {
+		sql      string
+		template string
+		params   []any
+	}{
+		{
+			sql:      "SELECT CAST(a AS CHAR(10)), CAST(b AS DECIMAL(10,2)), CONVERT(c, SIGNED), BINARY d FROM t WHERE CAST(e AS UNSIGNED) = 5",
+			template: "SELECT CAST(a AS CHAR(10)), CAST(b AS DECIMAL(10, 2)), CONVERT(c, SIGNED), BINARY d FROM t WHERE CAST(e AS UNSIGNED) eq ?",
+			params:   []any{int64(5)},
+		},
+		{
+			// 显式指定的字符集保留在模板中, 包括默认字符集 utf8mb4
+			sql:      "SELECT CAST(a AS CHAR CHARACTER SET utf8mb4), CAST(b AS CHAR(10) CHARSET latin1), CONVERT(c, CHAR CHARSET utf8mb4), CAST(d AS CHAR) FROM t",
+			template: "SELECT CAST(a AS CHAR CHARSET UTF8MB4), CAST(b AS CHAR(10) CHARSET LATIN1), CONVERT(c, CHAR CHARSET UTF8MB4), CAST(d AS CHAR) FROM t",
+			params:   []any{},
+		},
+		{
+			// 字符集名称保留在模板中
+			sql:      "SELECT CONVERT(name USING latin1), CHAR(77, 78), CHAR(77 USING ascii) FROM t",
+			template: "SELECT CONVERT(name USING latin1), CHAR(?, ?), CHAR(? USING ascii) FROM t",
+			params:   []any{int64(77), int64(78), int64(77)},
+		},
+		{
+			sql:      "SELECT TRIM(a), TRIM('x' FROM a), TRIM(LEADING 'x' FROM a), TRIM(TRAILING FROM a) FROM t",
+			template: "SELECT TRIM(a), TRIM(? FROM a), TRIM(LEADING ? FROM a), TRIM(TRAILING ? FROM a) FROM t",
+			params:   []any{"x", "x", " "},
+		},
+		{
+			sql:      "SELECT EXTRACT(YEAR_MONTH FROM d), TIMESTAMPDIFF(DAY, a, b), TIMESTAMPADD(MINUTE, 5, a), GET_FORMAT(DATE, 'USA') FROM t",
+			template: "SELECT EXTRACT(YEAR_MONTH FROM d), TIMESTAMPDIFF(DAY, a, b), TIMESTAMPADD(MINUTE, ?, a), GET_FORMAT(DATE, ?) FROM t",
+			params:   []any{int64(5), "USA"},
+		},
+		{
+			// 间隔可以是任意表达式
+			sql:      "SELECT DATE_ADD(d, INTERVAL 1 DAY), DATE_SUB(NOW(), INTERVAL n HOUR), ADDDATE(d, 5) FROM t",
+			template: "SELECT DATE_ADD(d, INTERVAL ? DAY), DATE_SUB(NOW(), INTERVAL n HOUR), ADDDATE(d, INTERVAL ? DAY) FROM t",
+			params:   []any{int64(1), int64(5)},
+		},
+		{
+			sql:      "SELECT POSITION('a' IN b), WEIGHT_STRING(a AS CHAR(4)) FROM t",
+			template: "SELECT POSITION(? IN b), WEIGHT_STRING(a AS CHAR(4)) FROM t",
+			params:   []any{"a"},
+		},
+		{
+			sql:      "SELECT * FROM t WHERE d > DATE '2025-01-01' AND e < TIMESTAMP '2025-01-01 00:00:00' AND f = TIME '10:00:00'",
+			template: "SELECT * FROM t WHERE d gt CAST(? AS DATE) and e lt CAST(? AS DATETIME) and f eq CAST(? AS TIME)",
+			params:   []any{"2025-01-01", "2025-01-01 00:00:00", "10:00:00"},
+		},
+		{
+			// -> 与 ->> 解析为 JSON_EXTRACT 与 JSON_UNQUOTE(JSON_EXTRACT), 函数名与显式调用一致
+			sql:      "SELECT doc->'$.a', doc->>'$.b', JSON_SET(doc, '$.x', 1) FROM t WHERE 3 MEMBER OF (doc->'$.arr')",
+			template: "SELECT JSON_EXTRACT(doc, ?), JSON_UNQUOTE(JSON_EXTRACT(doc, ?)), JSON_SET(doc, ?, ?) FROM t WHERE ? MEMBER OF (JSON_EXTRACT(doc, ?))",
+			params:   []any{"$.a", "$.b", "$.x", int64(1), int64(3), "$.arr"},
+		},
+	}
+
+	for _, tc := range testCases {
+		stmts, err := psr.ExtractStatements(tc.sql)
+		as.Nil(err, tc.sql)
+		as.Len(stmts, 1)
+		as.Equal(tc.template, stmts[0].TemplatizedSQL, tc.sql)
+		as.Equal(tc.params, stmts[0].Params, tc.sql)
+		as.Empty(stmts[0].Warnings, tc.sql)
+	}
+
+	// 简写与显式调用的模板相同
+	short, err := psr.ExtractStatements("SELECT doc->'$.a', doc->>'$.b' FROM t")
+	as.Nil(err)
+	explicit, err := psr.ExtractStatements("SELECT JSON_EXTRACT(doc, '$.a'), JSON_UNQUOTE(JSON_EXTRACT(doc, '$.b')) FROM t")
+	as.Nil(err)
+	as.Equal(explicit[0].TemplatizedSQL, short[0].TemplatizedSQL)
+
+	// RenderModeSQL 下的模板可被重新解析
+	sqlPsr := NewExtractor(WithRenderMode(RenderModeSQL))
+	for _, tc := range testCases {
+		stmts, err := sqlPsr.ExtractStatements(tc.sql)
+		as.Nil(err)
+
+		_, _, err = sqlPsr.parser.Parse(stmts[0].TemplatizedSQL, "", "")
+		as.Nil(err, stmts[0].TemplatizedSQL)
+	}
+}
+
+func TestTemplatizeSQL_JSONPathMode(t *testing.T) {
+	t.Parallel()
+	as := assert.New(t)
+
+	sql := "UPDATE t SET doc = JSON_SET(doc, '$.k', 'v', '$.n', 1) " +
+		"WHERE doc->>'$.name' = 'kyden' AND JSON_CONTAINS(doc, '1', '$.ids') AND JSON_SEARCH(doc, 'one', 'a', NULL, '$.s') IS NULL"
+
+	stmts, err := NewExtractor().ExtractStatements(sql)
+	as.Nil(err)
+	as.Equal("UPDATE t SET doc eq JSON_SET(doc, ?, ?, ?, ?) WHERE JSON_UNQUOTE(JSON_EXTRACT(doc, ?)) eq ? "+
+		"and JSON_CONTAINS(doc, ?, ?) and JSON_SEARCH(doc, ?, ?, ?, ?) IS NULL", stmts[0].TemplatizedSQL)
+	as.Equal([]any{"$.k", "v", "$.n", int64(1), "$.name", "kyden", "1", "$.ids", "one", "a", nil, "$.s"}, stmts[0].Params)
+
+	stmts, err = NewExtractor(WithJSONPathMode(JSONPathKeep)).ExtractStatements(sql)
+	as.Nil(err)
+	as.Equal("UPDATE t SET doc eq JSON_SET(doc, '$.k', ?, '$.n', ?) WHERE JSON_UNQUOTE(JSON_EXTRACT(doc, '$.name')) eq ? "+
+		"and JSON_CONTAINS(doc, ?, '$.ids') and JSON_SEARCH(doc, ?, ?, ?, '$.s') IS NULL", stmts[0].TemplatizedSQL)
+	as.Equal([]any{"v", int64(1), "kyden", "1", "one", "a", nil}, stmts[0].Params)
+
+	// 非字面值的路径仍按表达式处理
+	stmts, err = NewExtractor(WithJSONPathMode(JSONPathKeep)).ExtractStatements("SELECT JSON_EXTRACT(doc, CONCAT('$.', k)) FROM t")
+	as.Nil(err)
+	as.Equal("SELECT JSON_EXTRACT(doc, CONCAT(?, k)) FROM t", stmts[0].TemplatizedSQL)
+	as.Equal([]any{"$."}, stmts[0].Params)
+}
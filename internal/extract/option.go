@@ -0,0 +1,222 @@
+package extract
+
+import (
+	"log/slog"
+	"regexp"
+
+	"github.com/pingcap/tidb/pkg/parser/mysql"
+	"github.com/samber/lo"
+)
+
+// RenderMode controls how operators are rendered in the templatized SQL.
+type RenderMode int
+
+const (
+	// RenderModeOpcode renders operators with their opcode names, e.g. `a eq ? and b gt ?`.
+	// It is the default mode and keeps existing fingerprints stable.
+	RenderModeOpcode RenderMode = iota
+
+	// RenderModeSQL renders operators as valid MySQL, e.g. `a = ? AND b > ?`,
+	// so that the templatized SQL can be parsed and executed again.
+	RenderModeSQL
+)
+
+// INListMode controls how the value list of an IN expression is rendered in the templatized SQL.
+type INListMode int
+
+const (
+	// INListCollapse renders the literal values of a list as a single placeholder, e.g. `IN (?)`,
+	// so that lists of different sizes share the same template. Param markers, columns and
+	// functions are kept as separate items. (default)
+	INListCollapse INListMode = iota
+
+	// INListExact keeps the arity of the value list, e.g. `IN (?, ?, ?)`.
+	INListExact
+
+	// INListBucket renders the value list as a placeholder marking the order of magnitude
+	// of its size: `IN (?)` for 1 value, `IN (?+)` for 2-10, `IN (?++)` for 11-100, and so on.
+	// The result groups lists of similar sizes but is not valid SQL.
+	INListBucket
+)
+
+// JSONPathMode controls how JSON path literals of JSON functions are rendered in the templatized SQL.
+type JSONPathMode int
+
+const (
+	// JSONPathParam parameterizes JSON path literals like any other literal,
+	// e.g. `JSON_EXTRACT(doc, ?)`. (default)
+	JSONPathParam JSONPathMode = iota
+
+	// JSONPathKeep keeps JSON path literals in the templatized SQL, e.g. `JSON_EXTRACT(doc, '$.name')`,
+	// so that queries reading different paths get different templates.
+	JSONPathKeep
+)
+
+// LiteralPosition is a set of positions whose literals can be kept in the templatized SQL
+// instead of being parameterized. Positions can be combined, e.g. `LiteralLimit | LiteralInterval`.
+type LiteralPosition uint
+
+const (
+	// LiteralLimit is the row count and offset of LIMIT, e.g. `LIMIT 10, 20`.
+	LiteralLimit LiteralPosition = 1 << iota
+
+	// LiteralInterval is the amount of INTERVAL expressions, e.g. `DATE_SUB(NOW(), INTERVAL 7 DAY)`.
+	LiteralInterval
+
+	// LiteralCaseWhen is any literal of CASE expressions, e.g. `CASE WHEN a > 0 THEN 'y' ELSE 'n' END`.
+	LiteralCaseWhen
+
+	// LiteralSelectList is the literals of the select list, e.g. `SELECT 1, 'a' AS tag`.
+	LiteralSelectList
+
+	// LiteralGroupBy is the literals of GROUP BY, e.g. `GROUP BY FLOOR(age / 10)`.
+	LiteralGroupBy
+
+	// LiteralAggrFunc is the arguments of aggregate functions, e.g. `COUNT(1)`, `SUM(IF(a > 5, 1, 0))`.
+	// It is the only position kept by default.
+	LiteralAggrFunc
+)
+
+// Option configures the Extractor.
+type Option func(*Extractor)
+
+// config 提取行为配置, 由 Extractor 持有, 其创建的 ExtractVisitor 共享
+type config struct {
+	renderMode   RenderMode      // 运算符的输出形式
+	inListMode   INListMode      // IN 列表的输出形式
+	jsonPathMode JSONPathMode    // JSON 函数中路径字面值的输出形式
+	schemaShard  ShardNamer      // 分库名称的识别规则
+	tableShard   ShardNamer      // 分表名称的识别规则
+	keepLiterals LiteralPosition // 字面值直接输出而不参数化的位置
+	logger       *slog.Logger    // 未处理节点等信息的日志输出
+	strict       bool            // 存在未处理节点时是否返回 UnhandledNodeError
+	tidbDigest   bool            // 是否计算 TiDB 的归一化 SQL 及摘要
+
+	sqlMode   mysql.SQLMode // 解析器的 SQL mode
+	charset   string        // 解析时使用的字符集
+	collation string        // 解析时使用的排序规则
+}
+
+func defaultConfig() config {
+	return config{
+		renderMode:   RenderModeOpcode,
+		inListMode:   INListCollapse,
+		jsonPathMode: JSONPathParam,
+		schemaShard:  NumericSuffixNamer(),
+		tableShard:   NumericSuffixNamer(),
+		keepLiterals: LiteralAggrFunc,
+		logger:       slog.New(slog.DiscardHandler),
+		sqlMode:      mysql.ModeNone,
+	}
+}
+
+// WithRenderMode sets how operators are rendered in the templatized SQL.
+func WithRenderMode(mode RenderMode) Option {
+	return func(e *Extractor) { e.cfg.renderMode = mode }
+}
+
+// WithINListMode sets how the value list of an IN expression is rendered in the templatized SQL.
+func WithINListMode(mode INListMode) Option {
+	return func(e *Extractor) { e.cfg.inListMode = mode }
+}
+
+// WithJSONPathMode sets how JSON path literals of JSON functions (json_extract, json_set,
+// the `->` and `->>` operators, etc.) are rendered in the templatized SQL.
+func WithJSONPathMode(mode JSONPathMode) Option {
+	return func(e *Extractor) { e.cfg.jsonPathMode = mode }
+}
+
+// WithShardPattern sets the pattern used to recognize sharded schema and table names.
+//
+// The part of the name matched by the first capturing group (or the whole match if the
+// pattern has no group) is replaced by `?`, e.g. `_(\d+)$` templatizes `tb_10` into `tb_?`.
+// A nil pattern restores the default rule, which recognizes names like `name_<digits>`.
+// It is a shorthand of WithShardNamer(RegexNamer(pattern)).
+func WithShardPattern(pattern *regexp.Regexp) Option {
+	if pattern == nil {
+		return WithShardNamer(nil)
+	}
+
+	return WithShardNamer(RegexNamer(pattern))
+}
+
+// WithShardNamer sets the rule used to recognize sharded schema and table names,
+// see WithSchemaShardNamer and WithTableShardNamer to set them separately.
+func WithShardNamer(namer ShardNamer) Option {
+	return func(e *Extractor) {
+		WithSchemaShardNamer(namer)(e)
+		WithTableShardNamer(namer)(e)
+	}
+}
+
+// WithSchemaShardNamer sets the rule used to recognize sharded schema names, e.g. `db_23`.
+// A nil namer restores the default rule NumericSuffixNamer.
+func WithSchemaShardNamer(namer ShardNamer) Option {
+	return func(e *Extractor) { e.cfg.schemaShard = lo.Ternary(namer != nil, namer, NumericSuffixNamer()) }
+}
+
+// WithTableShardNamer sets the rule used to recognize sharded table names, e.g. `tb_10`.
+// A nil namer restores the default rule NumericSuffixNamer.
+func WithTableShardNamer(namer ShardNamer) Option {
+	return func(e *Extractor) { e.cfg.tableShard = lo.Ternary(namer != nil, namer, NumericSuffixNamer()) }
+}
+
+// WithInlineAggrLiterals sets whether literals inside aggregate functions are kept in the
+// templatized SQL (default) or parameterized like any other literal.
+// It is equivalent to WithKeepLiterals(LiteralAggrFunc, inline).
+func WithInlineAggrLiterals(inline bool) Option {
+	return WithKeepLiterals(LiteralAggrFunc, inline)
+}
+
+// WithKeepLiterals sets whether literals at the given positions are kept in the templatized SQL
+// or parameterized. Positions not given keep their current policy, e.g.
+//
+//	WithKeepLiterals(LiteralLimit|LiteralInterval, true)
+//
+// keeps `LIMIT 10` and `INTERVAL 7 DAY` as is, so that queries with different page sizes
+// or time ranges get different templates.
+func WithKeepLiterals(positions LiteralPosition, keep bool) Option {
+	return func(e *Extractor) {
+		if keep {
+			e.cfg.keepLiterals |= positions
+		} else {
+			e.cfg.keepLiterals &^= positions
+		}
+	}
+}
+
+// WithLogger sets the logger used to report unhandled nodes, in addition to the warnings
+// returned with the results. Default discards the logs. A nil logger is ignored.
+func WithLogger(logger *slog.Logger) Option {
+	return func(e *Extractor) {
+		if logger != nil {
+			e.cfg.logger = logger
+		}
+	}
+}
+
+// WithStrict sets whether Extract returns an *UnhandledNodeError when some nodes
+// could not be fully templatized, instead of returning them as warnings.
+func WithStrict(strict bool) Option {
+	return func(e *Extractor) { e.cfg.strict = strict }
+}
+
+// WithTiDBDigest sets whether the normalized SQL and digest of each statement are computed
+// as TiDB does, i.e. the Normalized_sql and Digest of the slow log and statements_summary.
+func WithTiDBDigest(enable bool) Option {
+	return func(e *Extractor) { e.cfg.tidbDigest = enable }
+}
+
+// WithSQLMode sets the SQL mode of the parser, e.g. mysql.ModeANSIQuotes.
+func WithSQLMode(mode mysql.SQLMode) Option {
+	return func(e *Extractor) { e.cfg.sqlMode = mode }
+}
+
+// WithCharset sets the charset and collation used to parse the SQL.
+// Empty values use the parser defaults.
+func WithCharset(charset, collation string) Option {
+	return func(e *Extractor) {
+		e.cfg.charset = charset
+		e.cfg.collation = collation
+	}
+}
@@ -0,0 +1,272 @@
+package extract
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pingcap/tidb/pkg/parser/ast"
+
+	"github.com/kydenul/sql-extractor/models"
+)
+
+// paramRef 语句中的参数, column 为其绑定的列在 columns 中的位置, 未知时为 -1
+type paramRef struct {
+	models.ParamInfo
+
+	column int
+}
+
+// appendPlaceholder 添加参数占位符到 SQL 字符串, e.g. ?, IN 列表分桶后的 ?+
+func (v *ExtractVisitor) appendPlaceholder(placeholder string) {
+	v.builder.WriteString(placeholder)
+	v.placeholders++
+}
+
+// appendParam 添加最近一个占位符对应的参数, node 为参数的字面值节点, 无节点时为 nil
+func (v *ExtractVisitor) appendParam(value any, node ast.Node) {
+	offset := -1
+	if node != nil && node.OriginTextPosition() > 0 { // 0 表示无位置信息
+		offset = node.OriginTextPosition()
+	}
+
+	v.params = append(v.params, value)
+	v.paramInfos = append(v.paramInfos, paramRef{
+		ParamInfo: models.ParamInfo{
+			Placeholder: v.placeholders - 1,
+			Offset:      offset,
+			Clause:      v.clause,
+		},
+		column: -1,
+	})
+}
+
+// markKeptLiteral 记录保留在模板中的字面值节点的位置, 避免其被无位置信息的参数匹配
+func (v *ExtractVisitor) markKeptLiteral(node ast.Node) {
+	if node.OriginTextPosition() > 0 {
+		v.keptLiterals = append(v.keptLiterals, node.OriginTextPosition())
+	}
+}
+
+// bindParams 将 params[start:] 绑定到列 columns[col]
+func (v *ExtractVisitor) bindParams(start, col int) {
+	if col < 0 || col >= len(v.columns) {
+		return
+	}
+
+	for idx := start; idx < len(v.paramInfos); idx++ {
+		v.paramInfos[idx].column = col
+	}
+}
+
+// canonicalParamInfos 拷贝参数信息, 并填充其绑定的列, columns 为 canonicalColumns 的结果
+func canonicalParamInfos(params []paramRef, columns []models.Column) []models.ParamInfo {
+	if len(params) == 0 {
+		return nil
+	}
+
+	result := make([]models.ParamInfo, 0, len(params))
+	for _, p := range params {
+		if p.column >= 0 {
+			col := columns[p.column]
+			p.Column = &col
+		}
+		result = append(result, p.ParamInfo)
+	}
+
+	return result
+}
+
+// fillParamTexts 按原始 SQL 填充语句参数的字面值文本, keptLiterals 为保留在模板中的字面值的位置
+//
+// 无位置信息的参数 (e.g. LIMIT 的值) 按出现顺序匹配语句中其余值相同且已参数化的字面值
+func fillParamTexts(sql string, stmt *models.Statement, keptLiterals []int) {
+	claimed := make(map[int]bool, len(stmt.ParamInfos)+len(keptLiterals))
+	for _, offset := range keptLiterals {
+		claimed[offset] = true
+	}
+	for idx := range stmt.ParamInfos {
+		info := &stmt.ParamInfos[idx]
+		if info.Offset >= 0 && info.Offset < len(sql) {
+			info.Text = sql[info.Offset:literalEnd(sql, info.Offset)]
+			claimed[info.Offset] = true
+		}
+	}
+
+	var literals []int
+	for idx := range stmt.ParamInfos {
+		info := &stmt.ParamInfos[idx]
+		if info.Text != "" {
+			continue
+		}
+		info.Offset = -1
+
+		if literals == nil {
+			literals = literalOffsets(sql, stmt.StartOffset, stmt.EndOffset)
+		}
+
+		value := fmt.Sprint(stmt.Params[idx])
+		for _, offset := range literals {
+			text := sql[offset:literalEnd(sql, offset)]
+			if !claimed[offset] && unquoteLiteral(text) == value {
+				info.Offset, info.Text = offset, text
+				claimed[offset] = true
+				break
+			}
+		}
+	}
+}
+
+// literalEnd 返回从 sql[i] 开始的字面值的结束位置, e.g. 42, 1e3, 0x1F, 'a' 'b', X'1F', _utf8mb4'a', NULL
+func literalEnd(sql string, i int) int {
+	ch := sql[i]
+
+	switch {
+	case ch == '\'' || ch == '"':
+		end := skipQuoted(sql, i)
+
+		// 相邻的字符串会被拼接, e.g. 'a' 'b'
+		for {
+			next := end
+			for next < len(sql) && isSpace(sql[next]) {
+				next++
+			}
+			if next == len(sql) || sql[next] != '\'' && sql[next] != '"' {
+				return end
+			}
+			end = skipQuoted(sql, next)
+		}
+
+	case isDigit(ch) || ch == '.':
+		return skipNumber(sql, i)
+
+	case isIdentChar(ch):
+		end := skipIdent(sql, i)
+		if end < len(sql) && sql[end] == '\'' { // 带前缀的字符串
+			return skipQuoted(sql, end)
+		}
+		return end
+
+	default:
+		return i + 1
+	}
+}
+
+// literalOffsets 返回 sql[start:end] 中字符串及数字字面值的起始位置, 跳过注释及标识符
+func literalOffsets(sql string, start, end int) []int {
+	sql = sql[:end]
+
+	offsets := make([]int, 0)
+	for i := start; i < len(sql); {
+		ch := sql[i]
+
+		switch {
+		case ch == '#' || strings.HasPrefix(sql[i:], "--") && (i+2 == len(sql) || isSpace(sql[i+2])):
+			i = skipLine(sql, i)
+
+		case strings.HasPrefix(sql[i:], "/*") && !strings.HasPrefix(sql[i:], "/*!"):
+			if idx := strings.Index(sql[i+2:], "*/"); idx >= 0 {
+				i += idx + 4
+			} else {
+				i = len(sql)
+			}
+
+		case ch == '`':
+			i = skipQuoted(sql, i)
+
+		case ch == '\'' || ch == '"',
+			isDigit(ch) || ch == '.' && i+1 < len(sql) && isDigit(sql[i+1]):
+			offsets = append(offsets, i)
+			i = literalEnd(sql, i)
+
+		case isIdentChar(ch):
+			idx := skipIdent(sql, i)
+			if idx < len(sql) && sql[idx] == '\'' { // 带前缀的字符串
+				offsets = append(offsets, i)
+				idx = skipQuoted(sql, idx)
+			}
+			i = idx
+
+		default:
+			i++
+		}
+	}
+
+	return offsets
+}
+
+// unquoteLiteral 去除字符串字面值的引号, 用于与参数值比较
+func unquoteLiteral(text string) string {
+	if len(text) >= 2 && (text[0] == '\'' || text[0] == '"') && text[len(text)-1] == text[0] {
+		return text[1 : len(text)-1]
+	}
+
+	return text
+}
+
+func isSpace(ch byte) bool { return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r' || ch == '\f' }
+
+func isDigit(ch byte) bool { return '0' <= ch && ch <= '9' }
+
+// isIdentChar 判断字符能否作为未加引号的标识符的一部分
+func isIdentChar(ch byte) bool {
+	return ch == '_' || ch == '$' || ch >= 0x80 || isDigit(ch) || ('a' <= ch && ch <= 'z') || ('A' <= ch && ch <= 'Z')
+}
+
+// skipLine 跳过注释行, 返回下一行的起始位置
+func skipLine(sql string, i int) int {
+	if end := strings.IndexByte(sql[i:], '\n'); end >= 0 {
+		return i + end + 1
+	}
+
+	return len(sql)
+}
+
+// skipQuoted 跳过以 sql[i] 为引号的字符串或标识符, 返回结束引号之后的位置
+func skipQuoted(sql string, i int) int {
+	quote := sql[i]
+	for i++; i < len(sql); i++ {
+		switch {
+		case sql[i] == '\\' && quote != '`':
+			i++
+		case sql[i] == quote && i+1 < len(sql) && sql[i+1] == quote: // 'it''s'
+			i++
+		case sql[i] == quote:
+			return i + 1
+		}
+	}
+
+	return len(sql)
+}
+
+// skipNumber 跳过数字字面值, e.g. 42, 1.5, .5, 1e-3, 0x1F, 0b101
+func skipNumber(sql string, i int) int {
+	if strings.HasPrefix(sql[i:], "0x") || strings.HasPrefix(sql[i:], "0b") {
+		return skipIdent(sql, i)
+	}
+
+	for i < len(sql) && (isDigit(sql[i]) || sql[i] == '.') {
+		i++
+	}
+
+	if i < len(sql) && (sql[i] == 'e' || sql[i] == 'E') {
+		j := i + 1
+		if j < len(sql) && (sql[j] == '+' || sql[j] == '-') {
+			j++
+		}
+		if j < len(sql) && isDigit(sql[j]) {
+			for i = j; i < len(sql) && isDigit(sql[i]); i++ {
+			}
+		}
+	}
+
+	return i
+}
+
+// skipIdent 跳过未加引号的标识符
+func skipIdent(sql string, i int) int {
+	for i < len(sql) && isIdentChar(sql[i]) {
+		i++
+	}
+
+	return i
+}
@@ -0,0 +1,146 @@
+package extract
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kydenul/sql-extractor/models"
+)
+
+func TestExtractStatements_ParamInfos(t *testing.T) {
+	t.Parallel()
+	as := assert.New(t)
+	psr := NewExtractor()
+
+	// param 参数信息的简要描述, 忽略绑定的列
+	type param struct {
+		Placeholder int
+		Offset      int
+		Text        string
+		Clause      models.Clause
+		Column      string
+	}
+	params := func(stmt *models.Statement) []param {
+		result := make([]param, 0, len(stmt.ParamInfos))
+		for _, info := range stmt.ParamInfos {
+			p := param{Placeholder: info.Placeholder, Offset: info.Offset, Text: info.Text, Clause: info.Clause}
+			if info.Column != nil {
+				p.Column = info.Column.Name
+			}
+			result = append(result, p)
+		}
+		return result
+	}
+
+	testCases := []struct {
+		sql    string
+		params []param
+	}{
+		{
+			// IN 列表合并的值共用一个占位符, LIMIT 的值无位置信息, 按值匹配
+			sql: "SELECT a FROM t WHERE uid = 0x1F AND d = '2024-01-01' AND c IN (1e3, 2) AND n LIKE 'x%' LIMIT 10 OFFSET 5",
+			params: []param{
+				{0, 28, "0x1F", models.ClauseWhere, "uid"},
+				{1, 41, "'2024-01-01'", models.ClauseWhere, "d"},
+				{2, 64, "1e3", models.ClauseWhere, "c"},
+				{2, 69, "2", models.ClauseWhere, "c"},
+				{3, 83, "'x%'", models.ClauseWhere, "n"},
+				{4, 104, "5", models.ClauseLimit, ""},
+				{5, 94, "10", models.ClauseLimit, ""},
+			},
+		},
+		{
+			// 保留在模板中的字面值 (聚合函数中的值) 不参与匹配
+			sql: "SELECT COUNT(1) FROM t WHERE b='x' LIMIT 1",
+			params: []param{
+				{0, 31, "'x'", models.ClauseWhere, "b"},
+				{1, 41, "1", models.ClauseLimit, ""},
+			},
+		},
+		{
+			// 参数占位符计入序号
+			sql: "UPDATE t SET a = 5, b = b + 1 WHERE id = ? AND k = 6",
+			params: []param{
+				{0, 17, "5", models.ClauseSet, "a"},
+				{1, 28, "1", models.ClauseSet, ""},
+				{3, 51, "6", models.ClauseWhere, "k"},
+			},
+		},
+		{
+			sql: "INSERT INTO t (a, b) VALUES (1, 'x'), (2, _utf8mb4'y') ON DUPLICATE KEY UPDATE a = 9",
+			params: []param{
+				{0, 29, "1", models.ClauseValues, "a"},
+				{1, 32, "'x'", models.ClauseValues, "b"},
+				{2, 39, "2", models.ClauseValues, "a"},
+				{3, 42, "_utf8mb4'y'", models.ClauseValues, "b"},
+				{4, 83, "9", models.ClauseOnDuplicate, "a"},
+			},
+		},
+		{
+			// 分库分表名称中的 ? 不是参数占位符
+			sql: "SELECT * FROM db_1.t_2 WHERE x = 'a' 'b' AND y BETWEEN 1.50 AND 2 AND DATE_ADD(z, INTERVAL 3 DAY) > NOW()",
+			params: []param{
+				{0, 33, "'a' 'b'", models.ClauseWhere, "x"},
+				{1, 55, "1.50", models.ClauseWhere, "y"},
+				{2, 64, "2", models.ClauseWhere, "y"},
+				{3, 91, "3", models.ClauseWhere, ""},
+			},
+		},
+		{
+			// 注释中的值不参与匹配
+			sql: "SELECT * FROM t WHERE d > DATE '2024-01-01' AND /* 5 */ e = 5",
+			params: []param{
+				{0, 31, "'2024-01-01'", models.ClauseWhere, ""},
+				{1, 60, "5", models.ClauseWhere, "e"},
+			},
+		},
+		{
+			sql: "CREATE TABLE t (id INT COMMENT 'pk') COMMENT = 'tbl'",
+			params: []param{
+				{0, 31, "'pk'", models.ClauseNone, ""},
+				{1, 47, "'tbl'", models.ClauseNone, ""},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		stmts, err := psr.ExtractStatements(tc.sql)
+		as.Nil(err, tc.sql)
+		as.Len(stmts[0].ParamInfos, len(stmts[0].Params), tc.sql)
+		as.Equal(tc.params, params(stmts[0]), tc.sql)
+	}
+
+	// 偏移量为原始 SQL 中的位置
+	sql := "SELECT 1; DELETE FROM t WHERE id = 0b101"
+	stmts, err := psr.ExtractStatements(sql)
+	as.Nil(err)
+	info := stmts[1].ParamInfos[0]
+	as.Equal("0b101", sql[info.Offset:info.Offset+len(info.Text)])
+	as.Same(stmts[1].TableInfos[0], info.Column.Tables[0])
+
+	// 无参数
+	stmts, err = psr.ExtractStatements("SELECT a FROM t WHERE b = ?")
+	as.Nil(err)
+	as.Nil(stmts[0].ParamInfos)
+}
+
+func TestLiteralEnd(t *testing.T) {
+	t.Parallel()
+	as := assert.New(t)
+
+	for literal, text := range map[string]string{
+		"42)":             "42",
+		"1.5e-3,":         "1.5e-3",
+		"0x1F ":           "0x1F",
+		"X'1F' AND":       "X'1F'",
+		"'it''s' x":       "'it''s'",
+		`'a\'b'`:          `'a\'b'`,
+		"'a'  'b' AND":    "'a'  'b'",
+		`"dq")`:           `"dq"`,
+		"_utf8mb4'x' AND": "_utf8mb4'x'",
+		"NULL)":           "NULL",
+	} {
+		as.Equal(text, literal[:literalEnd(literal, 0)], literal)
+	}
+}
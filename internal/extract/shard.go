@@ -0,0 +1,153 @@
+package extract
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ShardNamer recognizes sharded schema or table names, e.g. `db_23`, `orders_2024_10`.
+type ShardNamer interface {
+	// Template returns the templatized name, in which the shard indexes are replaced by `?`,
+	// and the shard indexes in the order they appear in the name, e.g. `orders_2024_10`
+	// returns `orders_?` and ["2024", "10"]. ok is false if the name is not sharded.
+	Template(name string) (template string, indexes []string, ok bool)
+}
+
+// ShardNamerFunc is an adapter to allow the use of ordinary functions as ShardNamer.
+type ShardNamerFunc func(name string) (template string, indexes []string, ok bool)
+
+// Template calls f(name).
+func (f ShardNamerFunc) Template(name string) (string, []string, bool) { return f(name) }
+
+// NumericSuffixNamer recognizes names whose last `_` separated part is a number,
+// e.g. `tb_10` into `tb_?` and ["10"]. It is the default rule.
+func NumericSuffixNamer() ShardNamer {
+	return ShardNamerFunc(func(name string) (string, []string, bool) {
+		idx := strings.LastIndex(name, "_")
+		if idx < 0 {
+			return name, nil, false
+		}
+
+		if _, err := strconv.Atoi(name[idx+1:]); err != nil {
+			return name, nil, false
+		}
+
+		return name[:idx+1] + tablePlaceholder, []string{name[idx+1:]}, true
+	})
+}
+
+// MultiNumericSuffixNamer recognizes names ending with one or more `_` separated numbers,
+// all of which are collapsed into a single `?`, e.g. `orders_2024_10` into `orders_?` and ["2024", "10"].
+func MultiNumericSuffixNamer() ShardNamer {
+	return ShardNamerFunc(func(name string) (string, []string, bool) {
+		loc := multiNumericSuffix.FindStringIndex(name)
+		if loc == nil || loc[0] == 0 {
+			return name, nil, false
+		}
+
+		return name[:loc[0]+1] + tablePlaceholder, strings.Split(name[loc[0]+1:], "_"), true
+	})
+}
+
+var multiNumericSuffix = regexp.MustCompile(`_\d+(?:_\d+)*$`)
+
+// DateSuffixNamer recognizes names ending with a valid date, in the form of `_YYYYMMDD`,
+// `_YYYYMM`, `_YYYY_MM_DD` or `_YYYY_MM`, e.g. `log_20241016` into `log_?` and ["20241016"].
+// The date is reported as a single index.
+func DateSuffixNamer() ShardNamer {
+	return ShardNamerFunc(func(name string) (string, []string, bool) {
+		loc := dateSuffix.FindStringIndex(name)
+		if loc == nil || loc[0] == 0 {
+			return name, nil, false
+		}
+
+		date := name[loc[0]+1:]
+		for _, layout := range dateSuffixLayouts {
+			if _, err := time.Parse(layout, date); err == nil {
+				return name[:loc[0]+1] + tablePlaceholder, []string{date}, true
+			}
+		}
+
+		return name, nil, false
+	})
+}
+
+var (
+	dateSuffix        = regexp.MustCompile(`_(?:\d{8}|\d{6}|\d{4}_\d{2}_\d{2}|\d{4}_\d{2})$`)
+	dateSuffixLayouts = []string{"20060102", "200601", "2006_01_02", "2006_01"}
+)
+
+// HexSuffixNamer recognizes names ending with a `0x` prefixed hexadecimal number,
+// e.g. `user_0x1f` into `user_?` and ["0x1f"].
+func HexSuffixNamer() ShardNamer {
+	return ShardNamerFunc(func(name string) (string, []string, bool) {
+		loc := hexSuffix.FindStringIndex(name)
+		if loc == nil || loc[0] == 0 {
+			return name, nil, false
+		}
+
+		return name[:loc[0]+1] + tablePlaceholder, []string{name[loc[0]+1:]}, true
+	})
+}
+
+var hexSuffix = regexp.MustCompile(`_0[xX][0-9a-fA-F]+$`)
+
+// FixedWidthNamer recognizes names ending with exactly width digits, with or without
+// a `_` separator, e.g. `tbl00023` into `tbl?` and ["00023"] for a width of 5.
+func FixedWidthNamer(width int) ShardNamer {
+	return ShardNamerFunc(func(name string) (string, []string, bool) {
+		start := len(name) - width
+		if width <= 0 || start <= 0 || isDigit(name[start-1]) {
+			return name, nil, false
+		}
+
+		for idx := start; idx < len(name); idx++ {
+			if !isDigit(name[idx]) {
+				return name, nil, false
+			}
+		}
+
+		return name[:start] + tablePlaceholder, []string{name[start:]}, true
+	})
+}
+
+// RegexNamer recognizes names matching any of the patterns, which are tried in order.
+//
+// The part of the name matched by the first capturing group (or the whole match if the
+// pattern has no group) is replaced by `?` and reported as the shard index,
+// e.g. `_shard_([a-z]\d+)$` templatizes `t_shard_a3` into `t_shard_?` and ["a3"].
+func RegexNamer(patterns ...*regexp.Regexp) ShardNamer {
+	return ShardNamerFunc(func(name string) (string, []string, bool) {
+		for _, pattern := range patterns {
+			loc := pattern.FindStringSubmatchIndex(name)
+			if loc == nil {
+				continue
+			}
+
+			start, end := loc[0], loc[1]
+			if len(loc) >= 4 && loc[2] >= 0 {
+				start, end = loc[2], loc[3]
+			}
+
+			return name[:start] + tablePlaceholder + name[end:], []string{name[start:end]}, true
+		}
+
+		return name, nil, false
+	})
+}
+
+// ChainNamer tries the namers in order and returns the result of the first one recognizing
+// the name, e.g. ChainNamer(DateSuffixNamer(), NumericSuffixNamer()).
+func ChainNamer(namers ...ShardNamer) ShardNamer {
+	return ShardNamerFunc(func(name string) (string, []string, bool) {
+		for _, namer := range namers {
+			if template, indexes, ok := namer.Template(name); ok {
+				return template, indexes, true
+			}
+		}
+
+		return name, nil, false
+	})
+}
@@ -0,0 +1,126 @@
+package extract
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kydenul/sql-extractor/internal/testutil"
+	"github.com/kydenul/sql-extractor/models"
+)
+
+func TestShardNamer(t *testing.T) {
+	t.Parallel()
+	as := assert.New(t)
+
+	testCases := []struct {
+		name     string
+		namer    ShardNamer
+		input    string
+		template string
+		indexes  []string
+	}{
+		{"numeric suffix", NumericSuffixNamer(), "tb_10", "tb_?", []string{"10"}},
+		{"numeric suffix, last part only", NumericSuffixNamer(), "orders_2024_10", "orders_2024_?", []string{"10"}},
+		{"numeric suffix, no number", NumericSuffixNamer(), "user_info", "user_info", nil},
+
+		{"multi numeric suffix", MultiNumericSuffixNamer(), "orders_2024_10", "orders_?", []string{"2024", "10"}},
+		{"multi numeric suffix, single", MultiNumericSuffixNamer(), "tb_10", "tb_?", []string{"10"}},
+		{"multi numeric suffix, whole name", MultiNumericSuffixNamer(), "_10", "_10", nil},
+
+		{"date suffix", DateSuffixNamer(), "log_20241016", "log_?", []string{"20241016"}},
+		{"date suffix, month", DateSuffixNamer(), "log_202410", "log_?", []string{"202410"}},
+		{"date suffix, separated", DateSuffixNamer(), "orders_2024_10", "orders_?", []string{"2024_10"}},
+		{"date suffix, separated day", DateSuffixNamer(), "log_2024_10_16", "log_?", []string{"2024_10_16"}},
+		{"date suffix, invalid date", DateSuffixNamer(), "log_20241332", "log_20241332", nil},
+		{"date suffix, not a date", DateSuffixNamer(), "tb_10", "tb_10", nil},
+
+		{"hex suffix", HexSuffixNamer(), "user_0x1f", "user_?", []string{"0x1f"}},
+		{"hex suffix, no prefix", HexSuffixNamer(), "user_1f", "user_1f", nil},
+
+		{"fixed width", FixedWidthNamer(5), "tbl00023", "tbl?", []string{"00023"}},
+		{"fixed width, separated", FixedWidthNamer(3), "tb_007", "tb_?", []string{"007"}},
+		{"fixed width, too wide", FixedWidthNamer(5), "tbl000023", "tbl000023", nil},
+		{"fixed width, too narrow", FixedWidthNamer(5), "tbl0023", "tbl0023", nil},
+		{"fixed width, whole name", FixedWidthNamer(5), "00023", "00023", nil},
+
+		{
+			"regex list",
+			RegexNamer(regexp.MustCompile(`^db(\d+)$`), regexp.MustCompile(`_shard_([a-z]\d+)$`)),
+			"t_shard_a3", "t_shard_?", []string{"a3"},
+		},
+		{"regex without group", RegexNamer(regexp.MustCompile(`\d+$`)), "log20241016", "log?", []string{"20241016"}},
+		{"regex no match", RegexNamer(regexp.MustCompile(`_shard_(\d+)$`)), "t_shard_a3", "t_shard_a3", nil},
+
+		{"chain, first wins", ChainNamer(DateSuffixNamer(), NumericSuffixNamer()), "log_20241016", "log_?", []string{"20241016"}},
+		{"chain, fallback", ChainNamer(DateSuffixNamer(), NumericSuffixNamer()), "tb_10", "tb_?", []string{"10"}},
+		{"chain, none", ChainNamer(DateSuffixNamer(), HexSuffixNamer()), "tb_10", "tb_10", nil},
+	}
+
+	for _, tc := range testCases {
+		template, indexes := templateShardName(tc.namer, tc.input)
+		as.Equal(tc.template, template, tc.name)
+		as.Equal(tc.indexes, indexes, tc.name)
+	}
+}
+
+func TestTemplatizeSQL_ShardNamer(t *testing.T) {
+	t.Parallel()
+	as := assert.New(t)
+
+	sql := "SELECT * FROM db_3.orders_2024_10 JOIN logs.log_20241016 JOIN user_0x1f JOIN tbl00023"
+
+	// 默认规则仅识别 name_<digits>
+	template, tableInfos, _, _, _, err := NewExtractor().Extract(sql)
+	as.Nil(err)
+	as.Equal(
+		[]string{"SELECT * FROM db_?.orders_2024_? CROSS JOIN logs.log_? CROSS JOIN user_0x1f CROSS JOIN tbl00023"},
+		template,
+	)
+	as.Equal([]string{"3"}, tableInfos[0][0].SchemaShardIndexes())
+	as.Equal([]string{"10"}, tableInfos[0][0].TableShardIndexes())
+
+	// 分库与分表分别配置
+	template, tableInfos, _, _, _, err = NewExtractor(
+		WithSchemaShardNamer(RegexNamer(regexp.MustCompile(`^db_(\d)$`))),
+		WithTableShardNamer(ChainNamer(
+			DateSuffixNamer(),
+			MultiNumericSuffixNamer(),
+			HexSuffixNamer(),
+			FixedWidthNamer(5),
+		)),
+	).Extract(sql)
+	as.Nil(err)
+	as.Equal(
+		[]string{"SELECT * FROM db_?.orders_? CROSS JOIN logs.log_? CROSS JOIN user_? CROSS JOIN tbl?"},
+		template,
+	)
+	as.Equal([]*models.TableInfo{
+		testutil.WithShards(models.NewTableInfo("db_3", "orders_2024_10", "db_?", "orders_?"), []string{"3"}, []string{"2024_10"}),
+		testutil.WithShards(models.NewTableInfo("logs", "log_20241016", "logs", "log_?"), nil, []string{"20241016"}),
+		testutil.WithShards(models.NewTableInfo("", "user_0x1f", "", "user_?"), nil, []string{"0x1f"}),
+		testutil.WithShards(models.NewTableInfo("", "tbl00023", "", "tbl?"), nil, []string{"00023"}),
+	}, tableInfos[0])
+
+	// 分片序号: 多段序号无法表示为单个整数
+	shard, ok := tableInfos[0][0].SchemaShard()
+	as.True(ok)
+	as.Equal(3, shard)
+	_, ok = tableInfos[0][0].TableShard()
+	as.False(ok)
+	shard, ok = tableInfos[0][2].TableShard()
+	as.True(ok)
+	as.Equal(31, shard)
+	shard, ok = tableInfos[0][3].TableShard()
+	as.True(ok)
+	as.Equal(23, shard)
+
+	// nil 恢复默认规则
+	template, _, _, _, _, err = NewExtractor(
+		WithShardNamer(HexSuffixNamer()),
+		WithTableShardNamer(nil),
+	).Extract("SELECT * FROM db_0x1.tb_0x1 JOIN tb_1")
+	as.Nil(err)
+	as.Equal([]string{"SELECT * FROM db_?.tb_0x1 CROSS JOIN tb_?"}, template)
+}
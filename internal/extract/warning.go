@@ -0,0 +1,45 @@
+package extract
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pingcap/tidb/pkg/parser/ast"
+
+	"github.com/kydenul/sql-extractor/models"
+)
+
+// UnhandledNodeError is returned by Extract in strict mode when some nodes
+// could not be fully templatized.
+type UnhandledNodeError struct {
+	Warnings []models.Warning
+}
+
+func (e *UnhandledNodeError) Error() string {
+	var sb strings.Builder
+	sb.WriteString("unhandled node: ")
+	sb.WriteString(e.Warnings[0].String())
+
+	if len(e.Warnings) > 1 {
+		fmt.Fprintf(&sb, " (and %d more)", len(e.Warnings)-1)
+	}
+
+	return sb.String()
+}
+
+// reportUnhandled 记录无法完整模板化的节点, 模板化 SQL 可能不完整
+//
+// 节点的语句序号由 Extractor 补充, 无位置信息的节点使用语句的起始位置
+func (v *ExtractVisitor) reportUnhandled(node any, details string) {
+	warning := models.Warning{
+		NodeType: fmt.Sprintf("%T", node),
+		Clause:   v.clause,
+		Message:  details,
+	}
+
+	if n, ok := node.(ast.Node); ok {
+		warning.Offset = n.OriginTextPosition()
+	}
+
+	v.warnings = append(v.warnings, warning)
+}
@@ -0,0 +1,203 @@
+package extract
+
+import (
+	"strings"
+
+	"github.com/pingcap/tidb/pkg/parser/ast"
+
+	"github.com/kydenul/sql-extractor/models"
+)
+
+// windowOnlyFuncs 仅可作为窗口函数使用的函数, 其余带 OVER 的函数为聚合函数
+var windowOnlyFuncs = map[string]struct{}{
+	ast.WindowFuncRowNumber:   {},
+	ast.WindowFuncRank:        {},
+	ast.WindowFuncDenseRank:   {},
+	ast.WindowFuncCumeDist:    {},
+	ast.WindowFuncPercentRank: {},
+	ast.WindowFuncNtile:       {},
+	ast.WindowFuncLead:        {},
+	ast.WindowFuncLag:         {},
+	ast.WindowFuncFirstValue:  {},
+	ast.WindowFuncLastValue:   {},
+	ast.WindowFuncNthValue:    {},
+}
+
+// handleWindowFuncExpr 处理窗口函数
+//
+// e.g. ROW_NUMBER() OVER (PARTITION BY uid ORDER BY ts), LAG(x, ?, ?) OVER w
+//
+// 聚合窗口函数 (SUM(x) OVER ...) 的字面值与聚合函数一致, 其余窗口函数
+// (LEAD/LAG 的偏移量及默认值, NTILE, NTH_VALUE 等) 的字面值作为参数
+func (v *ExtractVisitor) handleWindowFuncExpr(node *ast.WindowFuncExpr) {
+	v.builder.WriteString(node.Name)
+	v.builder.WriteString("(")
+
+	if node.Distinct {
+		v.builder.WriteString("DISTINCT ")
+	}
+
+	v.appendWindowFuncArgs(node)
+	v.builder.WriteString(")")
+
+	// NTH_VALUE(x, ?) FROM LAST IGNORE NULLS
+	if node.FromLast {
+		v.builder.WriteString(" FROM LAST")
+	}
+	if node.IgnoreNull {
+		v.builder.WriteString(" IGNORE NULLS")
+	}
+
+	v.builder.WriteString(" OVER ")
+	node.Spec.Accept(v)
+}
+
+// appendWindowFuncArgs 添加窗口函数的参数到 SQL 字符串
+func (v *ExtractVisitor) appendWindowFuncArgs(node *ast.WindowFuncExpr) {
+	old := v.literalCtx
+	defer func() { v.literalCtx = old }()
+
+	// 仅对函数参数生效, 聚合窗口函数的窗口帧偏移量仍作为参数
+	if _, windowOnly := windowOnlyFuncs[strings.ToLower(node.Name)]; windowOnly {
+		v.literalCtx &^= LiteralAggrFunc
+	} else {
+		v.literalCtx |= LiteralAggrFunc
+	}
+
+	for idx := range node.Args {
+		if idx > 0 {
+			v.builder.WriteString(", ")
+		}
+
+		node.Args[idx].Accept(v)
+	}
+}
+
+// handleWindowSpec 处理窗口定义
+//
+// e.g. w, (PARTITION BY a ORDER BY b ROWS BETWEEN ? PRECEDING AND CURRENT ROW), (w ORDER BY b)
+func (v *ExtractVisitor) handleWindowSpec(node *ast.WindowSpec) {
+	// OVER w
+	if node.OnlyAlias {
+		v.builder.WriteString(node.Name.O)
+		return
+	}
+
+	parts := 0
+	writeSep := func() {
+		if parts > 0 {
+			v.builder.WriteString(" ")
+		}
+		parts++
+	}
+
+	v.builder.WriteString("(")
+
+	// 引用已命名的窗口
+	if node.Ref.O != "" {
+		writeSep()
+		v.builder.WriteString(node.Ref.O)
+	}
+
+	if node.PartitionBy != nil {
+		writeSep()
+		v.builder.WriteString("PARTITION BY ")
+		v.appendByItems(node.PartitionBy.Items)
+	}
+
+	if node.OrderBy != nil {
+		writeSep()
+		v.builder.WriteString("ORDER BY ")
+		v.appendByItems(node.OrderBy.Items)
+	}
+
+	if node.Frame != nil {
+		writeSep()
+		node.Frame.Accept(v)
+	}
+
+	v.builder.WriteString(")")
+}
+
+// handleFrameClause 处理窗口帧, 边界的偏移量作为参数
+//
+// e.g. ROWS BETWEEN ? PRECEDING AND CURRENT ROW, RANGE BETWEEN INTERVAL ? DAY PRECEDING AND UNBOUNDED FOLLOWING
+func (v *ExtractVisitor) handleFrameClause(node *ast.FrameClause) {
+	switch node.Type {
+	case ast.Rows:
+		v.builder.WriteString("ROWS")
+	case ast.Ranges:
+		v.builder.WriteString("RANGE")
+	case ast.Groups:
+		v.builder.WriteString("GROUPS")
+	}
+
+	v.builder.WriteString(" BETWEEN ")
+	node.Extent.Start.Accept(v)
+	v.builder.WriteString(" AND ")
+	node.Extent.End.Accept(v)
+}
+
+// handleFrameBound 处理窗口帧的边界
+func (v *ExtractVisitor) handleFrameBound(node *ast.FrameBound) {
+	if node.UnBounded {
+		v.builder.WriteString("UNBOUNDED")
+	}
+
+	switch node.Type {
+	case ast.CurrentRow:
+		v.builder.WriteString("CURRENT ROW")
+		return
+
+	case ast.Preceding, ast.Following:
+		if node.Unit != ast.TimeUnitInvalid {
+			v.builder.WriteString("INTERVAL ")
+		}
+
+		if node.Expr != nil && node.Unit != ast.TimeUnitInvalid {
+			v.appendInterval(node.Expr)
+		} else if node.Expr != nil {
+			node.Expr.Accept(v)
+		}
+
+		if node.Unit != ast.TimeUnitInvalid {
+			v.builder.WriteString(" ")
+			v.builder.WriteString(node.Unit.String())
+		}
+	}
+
+	if node.Type == ast.Preceding {
+		v.builder.WriteString(" PRECEDING")
+	} else {
+		v.builder.WriteString(" FOLLOWING")
+	}
+}
+
+// appendWindowClause 添加 SELECT 语句的 WINDOW 子句到 SQL 字符串
+//
+// e.g. WINDOW w AS (PARTITION BY a), w2 AS (w ORDER BY b)
+func (v *ExtractVisitor) appendWindowClause(specs []ast.WindowSpec) {
+	v.builder.WriteString(" WINDOW ")
+	v.clause = models.ClauseWindow
+
+	for idx := range specs {
+		if idx > 0 {
+			v.builder.WriteString(", ")
+		}
+
+		v.builder.WriteString(specs[idx].Name.O)
+		v.builder.WriteString(" AS ")
+		specs[idx].Accept(v)
+	}
+}
+
+// appendByItems 添加以逗号分隔的排序/分组项到 SQL 字符串
+func (v *ExtractVisitor) appendByItems(items []*ast.ByItem) {
+	for idx := range items {
+		if idx > 0 {
+			v.builder.WriteString(", ")
+		}
+
+		items[idx].Accept(v)
+	}
+}
@@ -0,0 +1,87 @@
+package extract
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kydenul/sql-extractor/models"
+)
+
+func TestTemplatizeSQL_WindowFunc(t *testing.T) {
+	t.Parallel()
+	as := assert.New(t)
+	psr := NewExtractor()
+
+	testCases := []struct {
+		sql      string
+		template string
+		params   []any
+	}{
+		{
+			sql:      "SELECT uid, ROW_NUMBER() OVER (PARTITION BY uid ORDER BY ts DESC) AS rn FROM events WHERE type = 'click'",
+			template: "SELECT uid, ROW_NUMBER() OVER (PARTITION BY uid ORDER BY ts DESC) AS rn FROM events WHERE type eq ?",
+			params:   []any{"click"},
+		},
+		{
+			sql:      "SELECT RANK() OVER (), DENSE_RANK() OVER (ORDER BY score), NTILE(4) OVER (ORDER BY score) FROM t",
+			template: "SELECT RANK() OVER (), DENSE_RANK() OVER (ORDER BY score), NTILE(?) OVER (ORDER BY score) FROM t",
+			params:   []any{int64(4)},
+		},
+		{
+			// LEAD/LAG 的偏移量及默认值
+			sql:      "SELECT LAG(price, 2, 0) OVER w, LEAD(price, 1, 'n/a') OVER w FROM t WINDOW w AS (PARTITION BY sku ORDER BY ts)",
+			template: "SELECT LAG(price, ?, ?) OVER w, LEAD(price, ?, ?) OVER w FROM t WINDOW w AS (PARTITION BY sku ORDER BY ts)",
+			params:   []any{int64(2), int64(0), int64(1), "n/a"},
+		},
+		{
+			// 窗口帧
+			sql:      "SELECT SUM(amount) OVER (ORDER BY ts ROWS BETWEEN 3 PRECEDING AND CURRENT ROW) FROM t",
+			template: "SELECT SUM(amount) OVER (ORDER BY ts ROWS BETWEEN ? PRECEDING AND CURRENT ROW) FROM t",
+			params:   []any{int64(3)},
+		},
+		{
+			sql:      "SELECT AVG(amount) OVER (ORDER BY ts ROWS 5 PRECEDING) FROM t",
+			template: "SELECT AVG(amount) OVER (ORDER BY ts ROWS BETWEEN ? PRECEDING AND CURRENT ROW) FROM t",
+			params:   []any{int64(5)},
+		},
+		{
+			sql: "SELECT NTH_VALUE(x, 2) FROM LAST IGNORE NULLS OVER (ORDER BY d " +
+				"RANGE BETWEEN INTERVAL 1 DAY PRECEDING AND UNBOUNDED FOLLOWING) FROM t",
+			template: "SELECT NTH_VALUE(x, ?) FROM LAST IGNORE NULLS OVER (ORDER BY d " +
+				"RANGE BETWEEN INTERVAL ? DAY PRECEDING AND UNBOUNDED FOLLOWING) FROM t",
+			params: []any{int64(2), int64(1)},
+		},
+		{
+			// 聚合窗口函数的字面值与聚合函数一致
+			sql:      "SELECT COUNT(1) OVER (w ROWS BETWEEN UNBOUNDED PRECEDING AND 1 FOLLOWING), SUM(DISTINCT x) OVER () FROM t WINDOW w AS (PARTITION BY a), w2 AS (w ORDER BY b)",
+			template: "SELECT COUNT(1) OVER (w ROWS BETWEEN UNBOUNDED PRECEDING AND ? FOLLOWING), SUM(DISTINCT x) OVER () FROM t WINDOW w AS (PARTITION BY a), w2 AS (w ORDER BY b)",
+			params:   []any{int64(1)},
+		},
+		{
+			sql:      "SELECT * FROM (SELECT id, ROW_NUMBER() OVER (PARTITION BY uid) AS rn FROM t) AS x WHERE rn <= 10",
+			template: "SELECT * FROM (SELECT id, ROW_NUMBER() OVER (PARTITION BY uid) AS rn FROM t) AS x WHERE rn le ?",
+			params:   []any{int64(10)},
+		},
+	}
+
+	for _, tc := range testCases {
+		stmts, err := psr.ExtractStatements(tc.sql)
+		as.Nil(err, tc.sql)
+		as.Len(stmts, 1)
+		as.Equal(tc.template, stmts[0].TemplatizedSQL, tc.sql)
+		as.Equal(tc.params, stmts[0].Params, tc.sql)
+		as.Equal(models.SQLOperationSelect, stmts[0].OpType)
+		as.Empty(stmts[0].Warnings, tc.sql)
+	}
+
+	// RenderModeSQL 下的模板可被重新解析
+	sqlPsr := NewExtractor(WithRenderMode(RenderModeSQL))
+	for _, tc := range testCases {
+		stmts, err := sqlPsr.ExtractStatements(tc.sql)
+		as.Nil(err)
+
+		_, _, err = sqlPsr.parser.Parse(stmts[0].TemplatizedSQL, "", "")
+		as.Nil(err, stmts[0].TemplatizedSQL)
+	}
+}
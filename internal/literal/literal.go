@@ -0,0 +1,129 @@
+// Package literal renders Go values as MySQL literals. It is shared by the
+// extractor, which keeps some literals in the templatized SQL, and by Rehydrate,
+// which replaces placeholders with parameters, so that both escape values the same way.
+package literal
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pingcap/tidb/pkg/parser/test_driver"
+)
+
+// datetimeLayout is the MySQL DATETIME literal layout with microsecond precision.
+const datetimeLayout = "2006-01-02 15:04:05.999999"
+
+// Format renders a value as a valid MySQL literal of the same type, e.g. 1e-10, X'1f', 'it\'s'.
+//
+// Supported types: nil (NULL), string, []byte, bool, integers, floats,
+// *test_driver.MyDecimal, test_driver.BinaryLiteral and time.Time.
+//
+//nolint:cyclop
+func Format(value any) (string, error) {
+	switch val := value.(type) {
+	case nil:
+		return "NULL", nil
+
+	case string:
+		return Quote(val), nil
+
+	case []byte:
+		return "X'" + hex.EncodeToString(val) + "'", nil
+
+	case test_driver.BinaryLiteral: // 0x1F, X'1F', b'101'
+		return "X'" + hex.EncodeToString(val) + "'", nil
+
+	case bool:
+		if val {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+
+	case int:
+		return strconv.Itoa(val), nil
+	case int8:
+		return strconv.FormatInt(int64(val), 10), nil
+	case int16:
+		return strconv.FormatInt(int64(val), 10), nil
+	case int32:
+		return strconv.FormatInt(int64(val), 10), nil
+	case int64:
+		return strconv.FormatInt(val, 10), nil
+	case uint:
+		return strconv.FormatUint(uint64(val), 10), nil
+	case uint8:
+		return strconv.FormatUint(uint64(val), 10), nil
+	case uint16:
+		return strconv.FormatUint(uint64(val), 10), nil
+	case uint32:
+		return strconv.FormatUint(uint64(val), 10), nil
+	case uint64:
+		return strconv.FormatUint(val, 10), nil
+
+	case float32:
+		return Float(float64(val), 32), nil
+	case float64:
+		return Float(val, 64), nil
+
+	case *test_driver.MyDecimal:
+		return val.String(), nil
+
+	case time.Time:
+		return "'" + val.Format(datetimeLayout) + "'", nil
+
+	default:
+		return "", fmt.Errorf("unsupported literal type: %T", value)
+	}
+}
+
+// Float renders a float as a MySQL floating-point literal. The exponent is always
+// present so that the literal is parsed as DOUBLE rather than an integer or DECIMAL,
+// e.g. 1e-10, 1000e0.
+func Float(val float64, bitSize int) string {
+	str := strconv.FormatFloat(val, 'g', -1, bitSize)
+	if !strings.ContainsAny(str, "eE") {
+		str += "e0"
+	}
+
+	return str
+}
+
+// Quote quotes and escapes a string as a MySQL string literal. NUL, quotes,
+// backslash and the control characters with a MySQL escape sequence are escaped,
+// other bytes are kept as is.
+func Quote(str string) string {
+	var builder strings.Builder
+	builder.Grow(len(str) + 2)
+
+	builder.WriteByte('\'')
+	for i := 0; i < len(str); i++ {
+		switch ch := str[i]; ch {
+		case 0:
+			builder.WriteString(`\0`)
+		case '\'':
+			builder.WriteString(`\'`)
+		case '"':
+			builder.WriteString(`\"`)
+		case '\b':
+			builder.WriteString(`\b`)
+		case '\n':
+			builder.WriteString(`\n`)
+		case '\r':
+			builder.WriteString(`\r`)
+		case '\t':
+			builder.WriteString(`\t`)
+		case '\x1a':
+			builder.WriteString(`\Z`)
+		case '\\':
+			builder.WriteString(`\\`)
+		default:
+			builder.WriteByte(ch)
+		}
+	}
+	builder.WriteByte('\'')
+
+	return builder.String()
+}
@@ -0,0 +1,116 @@
+package literal
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/pingcap/tidb/pkg/parser"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/parser/test_driver"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormat(t *testing.T) {
+	t.Parallel()
+	as := assert.New(t)
+
+	testCases := []struct {
+		value    any
+		expected string
+	}{
+		{nil, "NULL"},
+		{"kyden", "'kyden'"},
+		{[]byte{0x1f, 0xab}, "X'1fab'"},
+		{test_driver.BinaryLiteral{0x05}, "X'05'"},
+		{true, "TRUE"},
+		{false, "FALSE"},
+		{int(-1), "-1"},
+		{int8(-8), "-8"},
+		{int16(16), "16"},
+		{int32(32), "32"},
+		{int64(math.MinInt64), "-9223372036854775808"},
+		{uint(1), "1"},
+		{uint8(8), "8"},
+		{uint16(16), "16"},
+		{uint32(32), "32"},
+		{uint64(math.MaxUint64), "18446744073709551615"},
+		{float32(1.5), "1.5e0"},
+		{float64(1000), "1000e0"},
+		{1e-10, "1e-10"},
+		{decimal("9.75"), "9.75"},
+		{time.Date(2024, 1, 2, 3, 4, 5, 6000, time.UTC), "'2024-01-02 03:04:05.000006'"},
+	}
+
+	for _, tc := range testCases {
+		literal, err := Format(tc.value)
+		as.Nil(err)
+		as.Equal(tc.expected, literal)
+	}
+
+	_, err := Format(struct{}{})
+	as.EqualError(err, "unsupported literal type: struct {}")
+}
+
+func decimal(str string) *test_driver.MyDecimal {
+	dec := new(test_driver.MyDecimal)
+	if err := dec.FromString([]byte(str)); err != nil {
+		panic(err)
+	}
+
+	return dec
+}
+
+func TestQuote(t *testing.T) {
+	t.Parallel()
+	as := assert.New(t)
+
+	testCases := []struct {
+		str      string
+		expected string
+	}{
+		{"", `''`},
+		{"it's", `'it\'s'`},
+		{`a "b"`, `'a \"b\"'`},
+		{`C:\dir`, `'C:\\dir'`},
+		{"a\x00b", `'a\0b'`},
+		{"a\nb\r\n", `'a\nb\r\n'`},
+		{"\t\b\x1a", `'\t\b\Z'`},
+		{"\x01\x1f\x7f", "'\x01\x1f\x7f'"}, // 无转义序列的控制字符原样保留
+		{"中文", `'中文'`},
+	}
+
+	for _, tc := range testCases {
+		as.Equal(tc.expected, Quote(tc.str), tc.str)
+	}
+}
+
+// 引用后的字符串被 MySQL 解析器还原为原值
+func TestQuote_RoundTrip(t *testing.T) {
+	t.Parallel()
+	as := assert.New(t)
+
+	var control []byte
+	for ch := range 0x20 {
+		control = append(control, byte(ch))
+	}
+
+	strs := []string{
+		string(control),
+		"\x7f",
+		`'"\%_`,
+		"line1\nline2\r\n\x00end",
+		"中文\x1a",
+	}
+
+	p := parser.New()
+	for _, str := range strs {
+		stmt, err := p.ParseOneStmt("SELECT "+Quote(str), "", "")
+		if !as.Nil(err, str) {
+			continue
+		}
+
+		expr := stmt.(*ast.SelectStmt).Fields.Fields[0].Expr.(*test_driver.ValueExpr)
+		as.Equal(str, expr.GetString())
+	}
+}
@@ -0,0 +1,18 @@
+// Package testutil provides helpers shared by the tests of this module.
+package testutil
+
+import "github.com/kydenul/sql-extractor/models"
+
+// WithShards sets the shard indexes of ti, to build expected table infos.
+func WithShards(ti *models.TableInfo, schemaIndexes, tableIndexes []string) *models.TableInfo {
+	ti.SetSchemaShardIndexes(schemaIndexes)
+	ti.SetTableShardIndexes(tableIndexes)
+	return ti
+}
+
+// WithAccess sets the access mode of ti, to build expected table infos of statements that
+// do not only read the table, e.g. the target of an UPDATE.
+func WithAccess(ti *models.TableInfo, access models.AccessMode) *models.TableInfo {
+	ti.SetAccess(access)
+	return ti
+}
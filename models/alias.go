@@ -0,0 +1,71 @@
+package models
+
+import "strings"
+
+// AliasKind identifies what an alias refers to.
+type AliasKind string
+
+// String returns the string representation of the AliasKind.
+func (k AliasKind) String() string { return string(k) }
+
+const (
+	AliasTable   AliasKind = "TABLE"   // alias of a physical table, e.g. `FROM orders AS o`
+	AliasDerived AliasKind = "DERIVED" // alias of a derived table, e.g. `FROM (SELECT ...) AS d`
+	AliasCTE     AliasKind = "CTE"     // name of a common table expression, or alias of a reference to it
+)
+
+// Alias is a name by which a statement refers to a table source, e.g. `o` in `FROM orders AS o`.
+type Alias struct {
+	Name string    `json:"name"` // alias or CTE name, as written in the statement
+	Kind AliasKind `json:"kind"`
+
+	// Tables are the physical tables the alias resolves to: the aliased table, or the tables
+	// read by the derived table or the common table expression.
+	Tables []*TableInfo `json:"tables"`
+}
+
+// ResolveAlias returns the physical tables that a qualifier refers to, e.g. the `o` of the
+// column `o.uid`. The qualifier may be an alias, a CTE name, a table name, or a table name
+// with schema, and is matched case-insensitively. It returns nil if the qualifier is unknown.
+//
+// Aliases are collected for the whole statement, so an alias declared in several query blocks
+// (e.g. a subquery reusing `t`) resolves to the tables of all of them.
+func (s *Statement) ResolveAlias(qualifier string) []*TableInfo {
+	var tables []*TableInfo
+	for _, alias := range s.Aliases {
+		if strings.EqualFold(alias.Name, qualifier) {
+			tables = appendUniqueTables(tables, alias.Tables...)
+		}
+	}
+	if tables != nil {
+		return tables
+	}
+
+	for _, table := range s.TableInfos {
+		name, _ := table.TableNameWithSchema()
+		if strings.EqualFold(name, qualifier) || strings.EqualFold(table.TableName(), qualifier) {
+			tables = appendUniqueTables(tables, table)
+		}
+	}
+
+	return tables
+}
+
+// appendUniqueTables appends the tables that are not in dst yet.
+func appendUniqueTables(dst []*TableInfo, tables ...*TableInfo) []*TableInfo {
+	for _, table := range tables {
+		found := false
+		for _, t := range dst {
+			if t == table {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			dst = append(dst, table)
+		}
+	}
+
+	return dst
+}
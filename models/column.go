@@ -0,0 +1,27 @@
+package models
+
+// Column is a column referenced by a statement, e.g. `o.uid` in `WHERE o.uid = ?`.
+type Column struct {
+	Schema string `json:"schema,omitempty"` // schema qualifier, as written
+	Table  string `json:"table,omitempty"`  // table qualifier, as written: an alias, a CTE name or a table name
+	Name   string `json:"name"`             // column name, as written, or * for a wildcard
+
+	Clause Clause     `json:"clause"` // clause in which the column appears, e.g. WHERE
+	Access AccessMode `json:"access"` // WRITE for assigned and inserted columns, otherwise READ
+
+	// Tables are the physical tables the column may belong to. A qualified column belongs to
+	// the tables its qualifier resolves to, see Statement.ResolveAlias. An unqualified column
+	// belongs to the tables of the innermost query block with tables, which are all candidates
+	// when the block joins several tables. Tables is empty if the owning table is unknown.
+	Tables []*TableInfo `json:"tables,omitempty"`
+}
+
+// Qualifier returns the qualifier of the column as written, e.g. `db.t` for `db.t.a`,
+// or an empty string for an unqualified column.
+func (c *Column) Qualifier() string {
+	if c.Schema == "" {
+		return c.Table
+	}
+
+	return c.Schema + "." + c.Table
+}
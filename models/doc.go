@@ -0,0 +1,6 @@
+// Package models defines the types produced by the SQL extractor, such as the
+// operation type of a statement (SQLOpType) and the tables it references (TableInfo).
+//
+// The types are exported so that callers can declare functions, maps and fixtures
+// with them, and they support JSON (TableInfo) and text (SQLOpType) marshaling.
+package models
@@ -0,0 +1,285 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SQLOpType represents the type of SQL operation
+type SQLOpType string
+
+// String returns the string representation of the SQLOpType.
+func (s SQLOpType) String() string { return string(s) }
+
+// MarshalText implements encoding.TextMarshaler.
+func (s SQLOpType) MarshalText() ([]byte, error) { return []byte(s), nil }
+
+// UnmarshalText implements encoding.TextUnmarshaler. The text is matched case-insensitively
+// against the known operation types, and an unknown text returns an error.
+func (s *SQLOpType) UnmarshalText(text []byte) error {
+	op := SQLOpType(strings.ToUpper(strings.TrimSpace(string(text))))
+	if _, ok := sqlOpTypes[op]; !ok {
+		return fmt.Errorf("unknown SQL operation type: %q", text)
+	}
+
+	*s = op
+	return nil
+}
+
+const (
+	SQLOperationUnknown SQLOpType = "UNKNOWN"
+	SQLOperationSelect  SQLOpType = "SELECT"
+	SQLOperationInsert  SQLOpType = "INSERT"
+	SQLOperationReplace SQLOpType = "REPLACE"
+	SQLOperationUpdate  SQLOpType = "UPDATE"
+	SQLOperationDelete  SQLOpType = "DELETE"
+	SQLOperationExplain SQLOpType = "EXPLAIN"
+	SQLOperationShow    SQLOpType = "SHOW"
+
+	SQLOperationCreate      SQLOpType = "CREATE"
+	SQLOperationAlter       SQLOpType = "ALTER"
+	SQLOperationDrop        SQLOpType = "DROP"
+	SQLOperationTruncate    SQLOpType = "TRUNCATE"
+	SQLOperationRename      SQLOpType = "RENAME"
+	SQLOperationCreateIndex SQLOpType = "CREATE INDEX"
+	SQLOperationDropIndex   SQLOpType = "DROP INDEX"
+)
+
+// sqlOpTypes is the set of known operation types, used by UnmarshalText.
+var sqlOpTypes = map[SQLOpType]struct{}{
+	SQLOperationUnknown:     {},
+	SQLOperationSelect:      {},
+	SQLOperationInsert:      {},
+	SQLOperationReplace:     {},
+	SQLOperationUpdate:      {},
+	SQLOperationDelete:      {},
+	SQLOperationExplain:     {},
+	SQLOperationShow:        {},
+	SQLOperationCreate:      {},
+	SQLOperationAlter:       {},
+	SQLOperationDrop:        {},
+	SQLOperationTruncate:    {},
+	SQLOperationRename:      {},
+	SQLOperationCreateIndex: {},
+	SQLOperationDropIndex:   {},
+}
+
+// AccessMode is how a statement accesses a table.
+type AccessMode string
+
+// String returns the string representation of the AccessMode.
+func (a AccessMode) String() string { return string(a) }
+
+const (
+	AccessNone      AccessMode = ""           // unknown, e.g. tables of unsupported statements
+	AccessRead      AccessMode = "READ"       // rows are read, e.g. FROM of SELECT, source of INSERT ... SELECT
+	AccessWrite     AccessMode = "WRITE"      // rows are modified, e.g. target of INSERT, UPDATE and DELETE
+	AccessReadWrite AccessMode = "READ_WRITE" // rows are both read and modified, e.g. INSERT INTO t SELECT ... FROM t
+	AccessDDL       AccessMode = "DDL"        // target of CREATE, ALTER, DROP, TRUNCATE and RENAME
+	AccessMetadata  AccessMode = "METADATA"   // only metadata is read, e.g. SHOW COLUMNS, EXPLAIN
+)
+
+// Merge returns the access mode of a table accessed both as a and b in the same statement.
+//
+// DDL takes precedence over any other mode, reading and writing combine into READ_WRITE,
+// and METADATA or NONE is overridden by any other mode.
+func (a AccessMode) Merge(b AccessMode) AccessMode {
+	switch {
+	case a == b:
+		return a
+	case a == AccessDDL || b == AccessDDL:
+		return AccessDDL
+	case a == AccessNone:
+		return b
+	case b == AccessNone:
+		return a
+	case a == AccessMetadata:
+		return b
+	case b == AccessMetadata:
+		return a
+	default: // READ, WRITE, READ_WRITE
+		return AccessReadWrite
+	}
+}
+
+type TableInfo struct {
+	templatizedSchema    string // templated schema, e.g. db_?
+	templatizedTableName string // templated table name, e.g. tb_?
+
+	schema    string // original schema, e.g. db_23
+	tableName string // original table name, e.g. tb_10
+
+	schemaShardIndexes []string // shard indexes of the schema, e.g. ["23"]
+	tableShardIndexes  []string // shard indexes of the table name, e.g. ["10"]
+
+	access AccessMode // how the statement accesses the table, e.g. READ, WRITE
+
+	cte bool // whether the table name refers to a common table expression (WITH ... AS)
+}
+
+// NewTableInfo creates a new TableInfo object.
+// args should be 0 or 2 or 4
+//
+//   - 0: no arguments, returns an empty TableInfo.
+//
+//   - 2: the first is schema, the second is table name.
+//
+//   - 4: the first is schema, the second is table name,
+//     the third is templatized schema, and the fourth is templatized table name.
+//
+// The access mode of a non-empty TableInfo is READ, see SetAccess.
+func NewTableInfo(args ...string) *TableInfo {
+	if len(args) == 0 {
+		return &TableInfo{}
+	}
+
+	if len(args) == 2 {
+		return &TableInfo{
+			schema:    args[0],
+			tableName: args[1],
+			access:    AccessRead,
+		}
+	}
+
+	if len(args) == 4 {
+		return &TableInfo{
+			schema:               args[0],
+			tableName:            args[1],
+			templatizedSchema:    args[2],
+			templatizedTableName: args[3],
+			access:               AccessRead,
+		}
+	}
+
+	panic(
+		"invalid args: len(args) should be 0 or 2, the first half are schemas, the second half are table names.",
+	)
+}
+
+// TableNameWithSchema returns the table name with schema.
+// If the schema is empty, it returns the table name without schema.
+//
+// Returns:
+//   - string: the table name with schema, or the table name if the schema is empty
+//   - bool: whether the schema is empty
+func (t *TableInfo) TableNameWithSchema() (string, bool) {
+	if t.schema != "" {
+		return t.schema + "." + t.tableName, true
+	}
+	return t.tableName, false
+}
+
+func (t *TableInfo) SetTableName(tableName string) { t.tableName = tableName }
+func (t *TableInfo) TableName() string             { return t.tableName }
+func (t *TableInfo) SetSchema(schema string)       { t.schema = schema }
+func (t *TableInfo) Schema() string                { return t.schema }
+
+// IsCTE returns whether the table name refers to a common table expression
+// defined in a WITH clause rather than a physical table.
+func (t *TableInfo) IsCTE() bool     { return t.cte }
+func (t *TableInfo) SetCTE(cte bool) { t.cte = cte }
+
+// Access returns how the statement accesses the table, e.g. WRITE for the target of an UPDATE.
+func (t *TableInfo) Access() AccessMode          { return t.access }
+func (t *TableInfo) SetAccess(access AccessMode) { t.access = access }
+
+func (t *TableInfo) TemplatizedTableNameWithSchema() (string, bool) {
+	if t.templatizedSchema != "" {
+		return t.templatizedSchema + "." + t.templatizedTableName, true
+	}
+	return t.templatizedTableName, false
+}
+func (t *TableInfo) SetTemplatizedTableName(tableName string) { t.templatizedTableName = tableName }
+func (t *TableInfo) TemplatizedTableName() string             { return t.templatizedTableName }
+func (t *TableInfo) SetTemplatizedSchema(schema string)       { t.templatizedSchema = schema }
+func (t *TableInfo) TemplatizedSchema() string                { return t.templatizedSchema }
+
+// SchemaShardIndexes returns the shard indexes recognized in the schema, in the order they
+// appear, e.g. ["23"] for db_23, or nil if the schema is not sharded.
+func (t *TableInfo) SchemaShardIndexes() []string           { return t.schemaShardIndexes }
+func (t *TableInfo) SetSchemaShardIndexes(indexes []string) { t.schemaShardIndexes = indexes }
+
+// TableShardIndexes returns the shard indexes recognized in the table name, in the order they
+// appear, e.g. ["2024", "10"] for orders_2024_10, or nil if the table is not sharded.
+func (t *TableInfo) TableShardIndexes() []string           { return t.tableShardIndexes }
+func (t *TableInfo) SetTableShardIndexes(indexes []string) { t.tableShardIndexes = indexes }
+
+// SchemaShard returns the shard number of the schema, e.g. 23 for db_23.
+//
+// It returns false if the schema is not sharded, has more than one shard index, or the
+// index is not an integer. A `0x` prefixed index is parsed as hexadecimal, e.g. 31 for db_0x1f,
+// and any other index as decimal, e.g. 23 for db00023.
+func (t *TableInfo) SchemaShard() (int, bool) { return shardNumber(t.schemaShardIndexes) }
+
+// TableShard returns the shard number of the table name, e.g. 10 for tb_10.
+// See SchemaShard for the rules.
+func (t *TableInfo) TableShard() (int, bool) { return shardNumber(t.tableShardIndexes) }
+
+// shardNumber parses the only shard index as an integer.
+func shardNumber(indexes []string) (int, bool) {
+	if len(indexes) != 1 {
+		return 0, false
+	}
+
+	idx, base := indexes[0], 10
+	if len(idx) > 2 && (idx[:2] == "0x" || idx[:2] == "0X") {
+		idx, base = idx[2:], 16
+	}
+
+	num, err := strconv.ParseInt(idx, base, strconv.IntSize)
+	if err != nil {
+		return 0, false
+	}
+
+	return int(num), true
+}
+
+// tableInfoJSON is the JSON representation of TableInfo.
+type tableInfoJSON struct {
+	Schema               string `json:"schema"`
+	TableName            string `json:"table_name"`
+	TemplatizedSchema    string `json:"templatized_schema"`
+	TemplatizedTableName string `json:"templatized_table_name"`
+	CTE                  bool   `json:"cte,omitempty"`
+
+	SchemaShardIndexes []string `json:"schema_shard_indexes,omitempty"`
+	TableShardIndexes  []string `json:"table_shard_indexes,omitempty"`
+
+	Access AccessMode `json:"access,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (t TableInfo) MarshalJSON() ([]byte, error) {
+	return json.Marshal(tableInfoJSON{
+		Schema:               t.schema,
+		TableName:            t.tableName,
+		TemplatizedSchema:    t.templatizedSchema,
+		TemplatizedTableName: t.templatizedTableName,
+		CTE:                  t.cte,
+		SchemaShardIndexes:   t.schemaShardIndexes,
+		TableShardIndexes:    t.tableShardIndexes,
+		Access:               t.access,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (t *TableInfo) UnmarshalJSON(data []byte) error {
+	var raw tableInfoJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*t = TableInfo{
+		schema:               raw.Schema,
+		tableName:            raw.TableName,
+		templatizedSchema:    raw.TemplatizedSchema,
+		templatizedTableName: raw.TemplatizedTableName,
+		cte:                  raw.CTE,
+		schemaShardIndexes:   raw.SchemaShardIndexes,
+		tableShardIndexes:    raw.TableShardIndexes,
+		access:               raw.Access,
+	}
+	return nil
+}
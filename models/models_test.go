@@ -0,0 +1,273 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SQLOpType_String(t *testing.T) {
+	a := assert.New(t)
+
+	var temp SQLOpType = "SELECT"
+	a.Equal("SELECT", temp.String())
+
+	temp = SQLOperationUnknown
+	a.Equal("UNKNOWN", temp.String())
+
+	temp = SQLOperationSelect
+	a.Equal("SELECT", temp.String())
+
+	temp = SQLOperationDelete
+	a.Equal("DELETE", temp.String())
+
+	temp = SQLOperationExplain
+	a.Equal("EXPLAIN", temp.String())
+
+	temp = SQLOperationInsert
+	a.Equal("INSERT", temp.String())
+
+	temp = SQLOperationReplace
+	a.Equal("REPLACE", temp.String())
+
+	temp = SQLOperationUpdate
+	a.Equal("UPDATE", temp.String())
+
+	temp = SQLOperationCreateIndex
+	a.Equal("CREATE INDEX", temp.String())
+
+	temp = SQLOperationDropIndex
+	a.Equal("DROP INDEX", temp.String())
+}
+
+func TestNewTableInfo(t *testing.T) {
+	a := assert.New(t)
+
+	// Test with no arguments
+	ti := NewTableInfo()
+	a.NotNil(ti)
+	a.Empty(ti.Schema())
+	a.Empty(ti.TableName())
+
+	// Test with 2 arguments
+	ti1 := NewTableInfo("public", "users")
+	a.NotNil(ti1)
+	a.Equal("public", ti1.Schema())
+	a.Equal("users", ti1.TableName())
+	// Test with templatized table name and schema
+	ti1.SetTemplatizedTableName("{{users}}")
+	ti1.SetTemplatizedSchema("{{public}}")
+	a.Equal("{{users}}", ti1.TemplatizedTableName())
+	a.Equal("{{public}}", ti1.TemplatizedSchema())
+
+	// Test with 4 arguments
+	ti2 := NewTableInfo("public", "users", "{{public}}", "{{users}}")
+	a.NotNil(ti2)
+	a.Equal("public", ti2.Schema())
+	a.Equal("users", ti2.TableName())
+	a.Equal("{{public}}", ti2.TemplatizedSchema())
+	a.Equal("{{users}}", ti2.TemplatizedTableName())
+
+	// Test with invalid arguments
+	assert.Panics(t, func() {
+		NewTableInfo("invalid")
+	})
+}
+
+func TestTableInfo_Methods(t *testing.T) {
+	a := assert.New(t)
+
+	// Test regular table name and schema
+	ti := &TableInfo{}
+	ti.SetTableName("users")
+	ti.SetSchema("public")
+	a.Equal("users", ti.TableName())
+	a.Equal("public", ti.Schema())
+
+	// Test templatized table name and schema
+	ti.SetTemplatizedTableName("{{users}}")
+	ti.SetTemplatizedSchema("{{public}}")
+	a.Equal("{{users}}", ti.TemplatizedTableName())
+	a.Equal("{{public}}", ti.TemplatizedSchema())
+
+	// Test TableNameWithSchema
+	name, hasSchema := ti.TableNameWithSchema()
+	if hasSchema {
+		a.Equal("public.users", name)
+	} else {
+		a.Equal("users", name)
+	}
+
+	// Test TemplatizedTableNameWithSchema
+	tName, tHasSchema := ti.TemplatizedTableNameWithSchema()
+	if tHasSchema {
+		a.Equal("{{public}}.{{users}}", tName)
+	} else {
+		a.Equal("{{users}}", tName)
+	}
+
+	// Test with empty schema
+	ti2 := &TableInfo{}
+	ti2.SetTableName("products")
+	ti2.SetTemplatizedTableName("{{products}}")
+
+	name, hasSchema = ti2.TableNameWithSchema()
+	a.False(hasSchema)
+	a.Equal("products", name)
+
+	tName, tHasSchema = ti2.TemplatizedTableNameWithSchema()
+	a.False(tHasSchema)
+	a.Equal("{{products}}", tName)
+}
+
+func TestTableInfo_CTE(t *testing.T) {
+	a := assert.New(t)
+
+	ti := NewTableInfo("", "recent", "", "recent")
+	a.False(ti.IsCTE())
+
+	ti.SetCTE(true)
+	a.True(ti.IsCTE())
+}
+
+func TestTableInfo_Shard(t *testing.T) {
+	a := assert.New(t)
+
+	testCases := []struct {
+		indexes []string
+		shard   int
+		ok      bool
+	}{
+		{nil, 0, false},
+		{[]string{"23"}, 23, true},
+		{[]string{"00023"}, 23, true},
+		{[]string{"20241016"}, 20241016, true},
+		{[]string{"0x1f"}, 31, true},
+		{[]string{"0X1F"}, 31, true},
+		{[]string{"2024", "10"}, 0, false},
+		{[]string{"2024_10"}, 0, false},
+		{[]string{"a3"}, 0, false},
+		{[]string{"0x"}, 0, false},
+	}
+
+	for _, tc := range testCases {
+		ti := NewTableInfo()
+		ti.SetSchemaShardIndexes(tc.indexes)
+		ti.SetTableShardIndexes(tc.indexes)
+
+		shard, ok := ti.SchemaShard()
+		a.Equal(tc.shard, shard, tc.indexes)
+		a.Equal(tc.ok, ok, tc.indexes)
+
+		shard, ok = ti.TableShard()
+		a.Equal(tc.shard, shard, tc.indexes)
+		a.Equal(tc.ok, ok, tc.indexes)
+	}
+
+	ti := NewTableInfo("db_1", "tb_2", "db_?", "tb_?")
+	ti.SetTableShardIndexes([]string{"2"})
+	_, ok := ti.SchemaShard()
+	a.False(ok)
+	shard, ok := ti.TableShard()
+	a.True(ok)
+	a.Equal(2, shard)
+}
+
+func TestAccessMode_Merge(t *testing.T) {
+	a := assert.New(t)
+
+	a.Equal(AccessRead, AccessRead.Merge(AccessRead))
+	a.Equal(AccessReadWrite, AccessRead.Merge(AccessWrite))
+	a.Equal(AccessReadWrite, AccessWrite.Merge(AccessRead))
+	a.Equal(AccessReadWrite, AccessReadWrite.Merge(AccessWrite))
+	a.Equal(AccessWrite, AccessNone.Merge(AccessWrite))
+	a.Equal(AccessRead, AccessRead.Merge(AccessMetadata))
+	a.Equal(AccessDDL, AccessDDL.Merge(AccessRead))
+	a.Equal(AccessDDL, AccessWrite.Merge(AccessDDL))
+	a.Equal(AccessMetadata, AccessMetadata.Merge(AccessNone))
+}
+
+func TestSQLOpType_Text(t *testing.T) {
+	a := assert.New(t)
+
+	text, err := SQLOperationCreateIndex.MarshalText()
+	a.NoError(err)
+	a.Equal("CREATE INDEX", string(text))
+
+	var op SQLOpType
+	a.NoError(op.UnmarshalText([]byte("select")))
+	a.Equal(SQLOperationSelect, op)
+
+	a.Error(op.UnmarshalText([]byte("MERGE")))
+	a.Equal(SQLOperationSelect, op)
+
+	// as map key
+	data, err := json.Marshal(map[SQLOpType]int{SQLOperationInsert: 2})
+	a.NoError(err)
+	a.JSONEq(`{"INSERT":2}`, string(data))
+
+	var counts map[SQLOpType]int
+	a.NoError(json.Unmarshal(data, &counts))
+	a.Equal(2, counts[SQLOperationInsert])
+}
+
+func TestTableInfo_JSON(t *testing.T) {
+	a := assert.New(t)
+
+	ti := NewTableInfo("db_1", "tb_2", "db_?", "tb_?")
+	data, err := json.Marshal(ti)
+	a.NoError(err)
+	a.JSONEq(`{"schema":"db_1","table_name":"tb_2","templatized_schema":"db_?","templatized_table_name":"tb_?","access":"READ"}`,
+		string(data))
+
+	var got TableInfo
+	a.NoError(json.Unmarshal(data, &got))
+	a.Equal(*ti, got)
+
+	cte := NewTableInfo("", "recent", "", "recent")
+	cte.SetCTE(true)
+	data, err = json.Marshal([]*TableInfo{cte})
+	a.NoError(err)
+	a.JSONEq(`[{"schema":"","table_name":"recent","templatized_schema":"","templatized_table_name":"recent","cte":true,"access":"READ"}]`,
+		string(data))
+
+	var list []*TableInfo
+	a.NoError(json.Unmarshal(data, &list))
+	a.Len(list, 1)
+	a.True(list[0].IsCTE())
+	a.Equal("recent", list[0].TableName())
+
+	sharded := NewTableInfo("db_1", "orders_2024_10", "db_?", "orders_?")
+	sharded.SetSchemaShardIndexes([]string{"1"})
+	sharded.SetTableShardIndexes([]string{"2024", "10"})
+	sharded.SetAccess(AccessWrite)
+	data, err = json.Marshal(sharded)
+	a.NoError(err)
+	a.JSONEq(`{"schema":"db_1","table_name":"orders_2024_10","templatized_schema":"db_?",`+
+		`"templatized_table_name":"orders_?","schema_shard_indexes":["1"],"table_shard_indexes":["2024","10"],"access":"WRITE"}`,
+		string(data))
+
+	got = TableInfo{}
+	a.NoError(json.Unmarshal(data, &got))
+	a.Equal(*sharded, got)
+
+	a.Error(json.Unmarshal([]byte(`{"schema":1}`), &got))
+}
+
+func TestColumn_Qualifier(t *testing.T) {
+	a := assert.New(t)
+
+	a.Equal("", (&Column{Name: "a"}).Qualifier())
+	a.Equal("o", (&Column{Table: "o", Name: "a"}).Qualifier())
+	a.Equal("db.t", (&Column{Schema: "db", Table: "t", Name: "a"}).Qualifier())
+}
+
+func TestStatement_PredicateParams(t *testing.T) {
+	a := assert.New(t)
+
+	stmt := &Statement{Params: []any{int64(1), "a%", int64(3)}}
+	a.Equal([]any{"a%", int64(3)}, stmt.PredicateParams(&Predicate{ParamIndexes: []int{1, 2}}))
+	a.Equal([]any{}, stmt.PredicateParams(&Predicate{}))
+	a.Equal([]any{}, stmt.PredicateParams(&Predicate{ParamIndexes: []int{3}}))
+}
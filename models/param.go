@@ -0,0 +1,17 @@
+package models
+
+// ParamInfo describes a parameter of a statement, see Statement.Params.
+type ParamInfo struct {
+	// Placeholder is the ordinal of the `?` the parameter fills in the templatized SQL, starting
+	// from 0. Parameter markers count as placeholders, the `?` of templatized shard names does not.
+	// The values of a collapsed IN list share one placeholder.
+	Placeholder int `json:"placeholder"`
+
+	Offset int    `json:"offset"`         // byte offset of the literal in the raw SQL, or -1 if unknown
+	Text   string `json:"text,omitempty"` // original literal text, e.g. 0x1F, '2024-01-01', 1e3, empty if unknown
+	Clause Clause `json:"clause"`         // clause in which the parameter appears, e.g. WHERE
+
+	// Column is the column the parameter is compared with (see Statement.Predicates),
+	// assigned to, or inserted into. It is nil if unknown.
+	Column *Column `json:"column,omitempty"`
+}
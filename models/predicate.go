@@ -0,0 +1,72 @@
+package models
+
+// PredicateOp is the operator of a predicate.
+type PredicateOp string
+
+// String returns the string representation of the PredicateOp.
+func (op PredicateOp) String() string { return string(op) }
+
+const (
+	PredicateEQ         PredicateOp = "="
+	PredicateNE         PredicateOp = "!="
+	PredicateLT         PredicateOp = "<"
+	PredicateLE         PredicateOp = "<="
+	PredicateGT         PredicateOp = ">"
+	PredicateGE         PredicateOp = ">="
+	PredicateNullEQ     PredicateOp = "<=>"
+	PredicateIn         PredicateOp = "IN"
+	PredicateNotIn      PredicateOp = "NOT IN"
+	PredicateBetween    PredicateOp = "BETWEEN"
+	PredicateNotBetween PredicateOp = "NOT BETWEEN"
+	PredicateLike       PredicateOp = "LIKE"
+	PredicateNotLike    PredicateOp = "NOT LIKE"
+	PredicateILike      PredicateOp = "ILIKE"
+	PredicateNotILike   PredicateOp = "NOT ILIKE"
+)
+
+// LogicOp is a logical operator enclosing a predicate.
+type LogicOp string
+
+// String returns the string representation of the LogicOp.
+func (op LogicOp) String() string { return string(op) }
+
+const (
+	LogicAnd LogicOp = "AND"
+	LogicOr  LogicOp = "OR"
+	LogicXor LogicOp = "XOR"
+	LogicNot LogicOp = "NOT"
+)
+
+// Predicate is a comparison of a column against values, e.g. `uid = ?`, `id IN (?, ?)`,
+// `created BETWEEN ? AND ?` or `name LIKE ?`. The column may be on either side of a binary
+// comparison, which is normalized with the column on the left, e.g. `? < a` into `a > ?`.
+//
+// Comparisons against other columns, functions or subqueries are not predicates, nor are
+// comparisons of select list aliases, e.g. `cnt > ?` in `SELECT COUNT(*) AS cnt ... HAVING cnt > ?`.
+type Predicate struct {
+	Column   Column      `json:"column"`   // compared column, also listed in Statement.Columns
+	Operator PredicateOp `json:"operator"` // e.g. =, IN, BETWEEN, LIKE
+
+	// ParamIndexes are the indexes in Statement.Params of the compared values, in the order
+	// they appear. Parameter markers and literals kept in the templatized SQL have no index.
+	ParamIndexes []int `json:"param_indexes,omitempty"`
+
+	// Context are the logical operators enclosing the predicate, from the outermost to the
+	// innermost, e.g. [AND OR] for `b = ?` in `a = ? AND (b = ? OR c = ?)`. It is empty if the
+	// predicate is the whole condition. Chained operators, e.g. `a = ? AND b = ? AND c = ?`, are
+	// recorded once, and subqueries start a new context.
+	Context []LogicOp `json:"context,omitempty"`
+}
+
+// PredicateParams returns the values the predicate compares its column against,
+// e.g. [42] for `uid = 42`.
+func (s *Statement) PredicateParams(p *Predicate) []any {
+	params := make([]any, 0, len(p.ParamIndexes))
+	for _, idx := range p.ParamIndexes {
+		if idx >= 0 && idx < len(s.Params) {
+			params = append(params, s.Params[idx])
+		}
+	}
+
+	return params
+}
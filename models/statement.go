@@ -0,0 +1,29 @@
+package models
+
+// Statement is the extraction result of a single SQL statement.
+//
+// A raw SQL may contain multiple statements separated by semicolons, and each of them
+// is extracted into a Statement, in the order they appear in the raw SQL.
+type Statement struct {
+	Index int `json:"index"` // index of the statement in the raw SQL, starting from 0
+
+	Text        string `json:"text"`         // original statement text, without surrounding spaces and the trailing semicolon
+	StartOffset int    `json:"start_offset"` // byte offset of the first character of Text in the raw SQL
+	EndOffset   int    `json:"end_offset"`   // byte offset right after the last character of Text in the raw SQL
+
+	TemplatizedSQL string       `json:"templatized_sql"`       // templatized SQL
+	Params         []any        `json:"params"`                // parameters: where conditions, order by, limit, offset
+	ParamInfos     []ParamInfo  `json:"param_infos,omitempty"` // position, clause and column of each parameter, parallel to Params
+	TableInfos     []*TableInfo `json:"table_infos"`           // table infos: Schema, Tablename
+	OpType         SQLOpType    `json:"op_type"`               // operation type: SELECT, INSERT, UPDATE, DELETE
+	Hash           string       `json:"hash"`                  // hash of the templatized SQL
+	HasParamMarker bool         `json:"has_param_marker"`      // whether the statement contains parameter markers
+	Aliases        []Alias      `json:"aliases,omitempty"`     // aliases and CTE names, in the order they are declared
+	Columns        []Column     `json:"columns,omitempty"`     // referenced columns, in the order they appear
+	Predicates     []Predicate  `json:"predicates,omitempty"`  // comparisons of columns against values, in the order they appear
+
+	TiDBNormalizedSQL string `json:"tidb_normalized_sql,omitempty"` // normalized SQL of the TiDB slow log, if enabled
+	TiDBDigest        string `json:"tidb_digest,omitempty"`         // digest of the TiDB slow log, if enabled
+
+	Warnings []Warning `json:"warnings,omitempty"` // nodes that could not be fully templatized
+}
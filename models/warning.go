@@ -0,0 +1,47 @@
+package models
+
+import "fmt"
+
+// Clause identifies the clause of a statement in which a node appears.
+type Clause string
+
+// String returns the string representation of the Clause.
+func (c Clause) String() string { return string(c) }
+
+const (
+	ClauseNone        Clause = ""         // statement level, or not inside a specific clause
+	ClauseWith        Clause = "WITH"     // common table expressions
+	ClauseSelect      Clause = "SELECT"   // select list
+	ClauseFrom        Clause = "FROM"     // table references, including the tables of UPDATE and DELETE
+	ClauseOn          Clause = "ON"       // join conditions
+	ClauseWhere       Clause = "WHERE"    // where conditions
+	ClauseGroupBy     Clause = "GROUP BY" // group by items
+	ClauseHaving      Clause = "HAVING"   // having conditions
+	ClauseWindow      Clause = "WINDOW"   // named window definitions
+	ClauseOrderBy     Clause = "ORDER BY" // order by items
+	ClauseLimit       Clause = "LIMIT"    // limit and offset
+	ClauseInto        Clause = "INTO"     // target table and column list of INSERT and REPLACE
+	ClauseSet         Clause = "SET"      // assignments of UPDATE and INSERT ... SET
+	ClauseValues      Clause = "VALUES"   // value lists of INSERT and REPLACE
+	ClauseOnDuplicate Clause = "ON DUPLICATE KEY UPDATE"
+)
+
+// Warning describes a node that could not be fully templatized, e.g. an unhandled
+// node type. The templatized SQL of the statement may be incomplete.
+type Warning struct {
+	StmtIndex int    `json:"stmt_index"` // index of the statement in the raw SQL, starting from 0
+	NodeType  string `json:"node_type"`  // Go type of the node, e.g. *ast.MatchAgainst
+	Offset    int    `json:"offset"`     // byte offset of the node, or of the statement if unknown, in the raw SQL
+	Clause    Clause `json:"clause"`     // clause in which the node appears
+	Message   string `json:"message"`    // details
+}
+
+// String returns a human readable description of the warning.
+func (w Warning) String() string {
+	clause := ""
+	if w.Clause != ClauseNone {
+		clause = " in " + string(w.Clause)
+	}
+
+	return fmt.Sprintf("statement %d, offset %d%s: %s: %s", w.StmtIndex, w.Offset, clause, w.NodeType, w.Message)
+}
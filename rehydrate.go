@@ -0,0 +1,98 @@
+package sqlextractor
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/kydenul/sql-extractor/internal/literal"
+)
+
+// ErrParamCountMismatch is returned by Rehydrate when the number of placeholders
+// in the template does not match the number of parameters.
+var ErrParamCountMismatch = errors.New("placeholder and param count mismatch")
+
+// Rehydrate rebuilds a concrete SQL statement from a templatized SQL and its
+// parameters, which is the inverse of Extract. Each `?` placeholder outside of
+// quoted strings and identifiers is replaced by the next parameter, rendered as
+// an escaped MySQL literal. Shard placeholders in templatized table names
+// (e.g. db_?.tb_?) are not parameters and are kept as is.
+//
+// The result is only executable if the template was produced with RenderModeSQL,
+// and only lossless if every placeholder has exactly one parameter (e.g. IN lists
+// collapsed into a single `?` carry several parameters and cause a mismatch).
+//
+// Supported parameter types: nil (NULL), string, []byte, bool, integers, floats,
+// *test_driver.MyDecimal, test_driver.BinaryLiteral and time.Time.
+//
+// Example:
+//
+//	sql, err := Rehydrate("SELECT * FROM users WHERE name = ? AND age > ?", []any{"kyden", int64(18)})
+//	// sql: SELECT * FROM users WHERE name = 'kyden' AND age > 18
+func Rehydrate(template string, params []any) (string, error) {
+	var (
+		builder strings.Builder
+		quote   byte // current quote character, 0 if not in a quoted string or identifier
+		idx     int  // index of the next parameter
+	)
+	builder.Grow(len(template))
+
+	for i := 0; i < len(template); i++ {
+		ch := template[i]
+
+		if quote != 0 {
+			builder.WriteByte(ch)
+			switch {
+			case ch == '\\' && quote != '`' && i+1 < len(template):
+				i++
+				builder.WriteByte(template[i])
+			case ch == quote && i+1 < len(template) && template[i+1] == quote: // escaped by doubling, e.g. 'it''s'
+				i++
+				builder.WriteByte(template[i])
+			case ch == quote:
+				quote = 0
+			}
+
+			continue
+		}
+
+		switch ch {
+		case '\'', '"', '`':
+			quote = ch
+			builder.WriteByte(ch)
+
+		case '?':
+			// shard placeholder in templatized identifiers, e.g. db_?.tb_?
+			if i > 0 && isIdentChar(template[i-1]) {
+				builder.WriteByte(ch)
+				continue
+			}
+
+			if idx >= len(params) {
+				return "", fmt.Errorf("%w: more than %d placeholders", ErrParamCountMismatch, len(params))
+			}
+
+			lit, err := literal.Format(params[idx])
+			if err != nil {
+				return "", fmt.Errorf("param %d: %w", idx, err)
+			}
+			builder.WriteString(lit)
+			idx++
+
+		default:
+			builder.WriteByte(ch)
+		}
+	}
+
+	if idx != len(params) {
+		return "", fmt.Errorf("%w: %d placeholders, %d params", ErrParamCountMismatch, idx, len(params))
+	}
+
+	return builder.String(), nil
+}
+
+// isIdentChar reports whether ch can be part of an unquoted identifier.
+func isIdentChar(ch byte) bool {
+	return ch == '_' || ch == '$' ||
+		('0' <= ch && ch <= '9') || ('a' <= ch && ch <= 'z') || ('A' <= ch && ch <= 'Z')
+}
@@ -0,0 +1,128 @@
+package sqlextractor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRehydrate(t *testing.T) {
+	t.Parallel()
+	as := assert.New(t)
+
+	testCases := []struct {
+		name     string
+		template string
+		params   []any
+		expected string
+	}{
+		{
+			name:     "no params",
+			template: "SELECT * FROM users",
+			params:   nil,
+			expected: "SELECT * FROM users",
+		},
+		{
+			name:     "string and integer",
+			template: "SELECT * FROM users WHERE name = ? AND age > ?",
+			params:   []any{"kyden", int64(18)},
+			expected: "SELECT * FROM users WHERE name = 'kyden' AND age > 18",
+		},
+		{
+			name:     "escaped string",
+			template: "SELECT * FROM users WHERE name = ?",
+			params:   []any{"it's a \"quote\"\\\n"},
+			expected: `SELECT * FROM users WHERE name = 'it\'s a \"quote\"\\\n'`,
+		},
+		{
+			name:     "NULL, bool, unsigned and float",
+			template: "UPDATE t SET a = ?, b = ?, c = ? WHERE d = ?",
+			params:   []any{nil, true, uint64(10), 1.5},
+			expected: "UPDATE t SET a = NULL, b = TRUE, c = 10 WHERE d = 1.5e0",
+		},
+		{
+			name:     "large float",
+			template: "SELECT * FROM t WHERE d < ?",
+			params:   []any{1e21},
+			expected: "SELECT * FROM t WHERE d < 1e+21",
+		},
+		{
+			name:     "binary",
+			template: "SELECT * FROM t WHERE b = ?",
+			params:   []any{[]byte{0x1f, 0xab}},
+			expected: "SELECT * FROM t WHERE b = X'1fab'",
+		},
+		{
+			name:     "datetime",
+			template: "SELECT * FROM t WHERE created_at >= ?",
+			params:   []any{time.Date(2024, 1, 2, 3, 4, 5, 6000, time.UTC)},
+			expected: "SELECT * FROM t WHERE created_at >= '2024-01-02 03:04:05.000006'",
+		},
+		{
+			name:     "shard placeholder is kept",
+			template: "SELECT * FROM db_?.tb_? WHERE id = ?",
+			params:   []any{int64(1)},
+			expected: "SELECT * FROM db_?.tb_? WHERE id = 1",
+		},
+		{
+			name:     "placeholder inside quotes is kept",
+			template: "SELECT '?', `a?`, \"it''s ?\" FROM t WHERE a = ?",
+			params:   []any{int64(1)},
+			expected: "SELECT '?', `a?`, \"it''s ?\" FROM t WHERE a = 1",
+		},
+	}
+
+	for _, tc := range testCases {
+		sql, err := Rehydrate(tc.template, tc.params)
+		as.Nil(err, tc.name)
+		as.Equal(tc.expected, sql, tc.name)
+	}
+}
+
+func TestRehydrate_Error(t *testing.T) {
+	t.Parallel()
+	as := assert.New(t)
+
+	// too few params
+	_, err := Rehydrate("SELECT * FROM t WHERE a = ? AND b = ?", []any{int64(1)})
+	as.ErrorIs(err, ErrParamCountMismatch)
+
+	// too many params
+	_, err = Rehydrate("SELECT * FROM t WHERE a = ?", []any{int64(1), int64(2)})
+	as.ErrorIs(err, ErrParamCountMismatch)
+
+	// unsupported type
+	_, err = Rehydrate("SELECT * FROM t WHERE a = ?", []any{struct{}{}})
+	as.Error(err)
+}
+
+func TestRehydrate_RoundTrip(t *testing.T) {
+	t.Parallel()
+	as := assert.New(t)
+
+	sqls := []string{
+		"SELECT * FROM users WHERE name = 'it''s' AND age > 18 AND score <= 9.75 AND flag = 0x1F",
+		"INSERT INTO logs (a, b, c) VALUES ('x\\ny', NULL, 1e3)",
+		"UPDATE users SET name = 'kyden', cnt = cnt + 1 WHERE id = 100 LIMIT 1",
+		"DELETE FROM t WHERE created_at < '2024-01-01 00:00:00' AND b'101' = bits",
+		"SELECT * FROM users JOIN orders USING (id) NATURAL LEFT JOIN items WHERE users.age > 18",
+		"SELECT * FROM users STRAIGHT_JOIN orders ON users.id = orders.user_id WHERE orders.total >= 9.5",
+		"SELECT a, GROUP_CONCAT(b ORDER BY c SEPARATOR ';') FROM t WHERE d = 'x' GROUP BY a WITH ROLLUP",
+		"SELECT SQL_CALC_FOUND_ROWS * FROM t PARTITION (p0) USE INDEX (idx_a) WHERE a = 5 LIMIT 10 FOR UPDATE",
+	}
+
+	for _, sql := range sqls {
+		extractor := NewExtractor(sql, WithRenderMode(RenderModeSQL))
+		as.Nil(extractor.Extract())
+
+		rehydrated, err := Rehydrate(extractor.TemplatizedSQL()[0], extractor.Params()[0])
+		as.Nil(err, sql)
+
+		// extraction is lossless: the rehydrated SQL yields the same template and params
+		again := NewExtractor(rehydrated, WithRenderMode(RenderModeSQL))
+		as.Nil(again.Extract(), rehydrated)
+		as.Equal(extractor.TemplatizedSQL(), again.TemplatizedSQL(), rehydrated)
+		as.Equal(extractor.Params(), again.Params(), rehydrated)
+	}
+}
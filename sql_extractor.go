@@ -3,11 +3,198 @@ package sqlextractor
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"log/slog"
+	"regexp"
+
+	"github.com/pingcap/tidb/pkg/parser/mysql"
+	"github.com/samber/lo"
 
 	"github.com/kydenul/sql-extractor/internal/extract"
-	"github.com/kydenul/sql-extractor/internal/models"
+	"github.com/kydenul/sql-extractor/models"
+)
+
+// RenderMode controls how operators are rendered in the templatized SQL.
+type RenderMode = extract.RenderMode
+
+const (
+	// RenderModeOpcode renders operators with their opcode names, e.g. `a eq ? and b gt ?`. (default)
+	RenderModeOpcode = extract.RenderModeOpcode
+
+	// RenderModeSQL renders operators as valid MySQL, e.g. `a = ? AND b > ?`.
+	RenderModeSQL = extract.RenderModeSQL
+)
+
+// INListMode controls how the value list of an IN expression is rendered in the templatized SQL.
+type INListMode = extract.INListMode
+
+const (
+	// INListCollapse renders the literal values of a list as a single placeholder, e.g. `IN (?)`. (default)
+	INListCollapse = extract.INListCollapse
+
+	// INListExact keeps the arity of the value list, e.g. `IN (?, ?, ?)`.
+	INListExact = extract.INListExact
+
+	// INListBucket marks the order of magnitude of the list size, e.g. `IN (?+)` for 2-10 values.
+	INListBucket = extract.INListBucket
 )
 
+// JSONPathMode controls how JSON path literals of JSON functions are rendered in the templatized SQL.
+type JSONPathMode = extract.JSONPathMode
+
+const (
+	// JSONPathParam parameterizes JSON path literals, e.g. `JSON_EXTRACT(doc, ?)`. (default)
+	JSONPathParam = extract.JSONPathParam
+
+	// JSONPathKeep keeps JSON path literals in the templatized SQL, e.g. `JSON_EXTRACT(doc, '$.name')`.
+	JSONPathKeep = extract.JSONPathKeep
+)
+
+// LiteralPosition is a set of positions whose literals can be kept in the templatized SQL
+// instead of being parameterized, see WithKeepLiterals.
+type LiteralPosition = extract.LiteralPosition
+
+const (
+	// LiteralLimit is the row count and offset of LIMIT, e.g. `LIMIT 10, 20`.
+	LiteralLimit = extract.LiteralLimit
+
+	// LiteralInterval is the amount of INTERVAL expressions, e.g. `INTERVAL 7 DAY`.
+	LiteralInterval = extract.LiteralInterval
+
+	// LiteralCaseWhen is any literal of CASE expressions.
+	LiteralCaseWhen = extract.LiteralCaseWhen
+
+	// LiteralSelectList is the literals of the select list, e.g. `SELECT 1, 'a' AS tag`.
+	LiteralSelectList = extract.LiteralSelectList
+
+	// LiteralGroupBy is the literals of GROUP BY, e.g. `GROUP BY FLOOR(age / 10)`.
+	LiteralGroupBy = extract.LiteralGroupBy
+
+	// LiteralAggrFunc is the arguments of aggregate functions, e.g. `COUNT(1)`. (kept by default)
+	LiteralAggrFunc = extract.LiteralAggrFunc
+)
+
+// ShardNamer recognizes sharded schema or table names, see WithShardNamer.
+type ShardNamer = extract.ShardNamer
+
+// ShardNamerFunc is an adapter to allow the use of ordinary functions as ShardNamer.
+type ShardNamerFunc = extract.ShardNamerFunc
+
+// NumericSuffixNamer recognizes names like `tb_10` into `tb_?`. It is the default rule.
+func NumericSuffixNamer() ShardNamer { return extract.NumericSuffixNamer() }
+
+// MultiNumericSuffixNamer recognizes names like `orders_2024_10` into `orders_?`.
+func MultiNumericSuffixNamer() ShardNamer { return extract.MultiNumericSuffixNamer() }
+
+// DateSuffixNamer recognizes names ending with a valid date, like `log_20241016` into `log_?`.
+func DateSuffixNamer() ShardNamer { return extract.DateSuffixNamer() }
+
+// HexSuffixNamer recognizes names like `user_0x1f` into `user_?`.
+func HexSuffixNamer() ShardNamer { return extract.HexSuffixNamer() }
+
+// FixedWidthNamer recognizes names ending with exactly width digits, like `tbl00023` into `tbl?`.
+func FixedWidthNamer(width int) ShardNamer { return extract.FixedWidthNamer(width) }
+
+// RegexNamer recognizes names matching any of the patterns, replacing the part matched by the
+// first capturing group (or the whole match), e.g. `_shard_([a-z]\d+)$` for `t_shard_a3`.
+func RegexNamer(patterns ...*regexp.Regexp) ShardNamer { return extract.RegexNamer(patterns...) }
+
+// ChainNamer tries the namers in order and returns the result of the first one recognizing the name.
+func ChainNamer(namers ...ShardNamer) ShardNamer { return extract.ChainNamer(namers...) }
+
+// UnhandledNodeError is returned by Extract in strict mode when some nodes
+// could not be fully templatized. It carries the warnings of all statements.
+type UnhandledNodeError = extract.UnhandledNodeError
+
+// Option configures the Extractor.
+type Option func(*Extractor)
+
+// WithHasher sets the hash function of the templatized SQL. Default is sha256.
+func WithHasher(fn func([]byte) string) Option {
+	return func(e *Extractor) { e.hasher = fn }
+}
+
+// WithRenderMode sets how operators are rendered in the templatized SQL.
+func WithRenderMode(mode RenderMode) Option {
+	return func(e *Extractor) { e.extractOpts = append(e.extractOpts, extract.WithRenderMode(mode)) }
+}
+
+// WithINListMode sets how the value list of an IN expression is rendered in the templatized SQL.
+func WithINListMode(mode INListMode) Option {
+	return func(e *Extractor) { e.extractOpts = append(e.extractOpts, extract.WithINListMode(mode)) }
+}
+
+// WithJSONPathMode sets how JSON path literals of JSON functions (json_extract, json_set,
+// the `->` and `->>` operators, etc.) are rendered in the templatized SQL.
+func WithJSONPathMode(mode JSONPathMode) Option {
+	return func(e *Extractor) { e.extractOpts = append(e.extractOpts, extract.WithJSONPathMode(mode)) }
+}
+
+// WithShardPattern sets the pattern used to recognize sharded schema and table names.
+// The part matched by the first capturing group (or the whole match) is replaced by `?`,
+// e.g. `_(\d+)$` templatizes `tb_10` into `tb_?`.
+func WithShardPattern(pattern *regexp.Regexp) Option {
+	return func(e *Extractor) { e.extractOpts = append(e.extractOpts, extract.WithShardPattern(pattern)) }
+}
+
+// WithShardNamer sets the rule used to recognize sharded schema and table names.
+// The recognized shard indexes are reported by TableInfo.
+func WithShardNamer(namer ShardNamer) Option {
+	return func(e *Extractor) { e.extractOpts = append(e.extractOpts, extract.WithShardNamer(namer)) }
+}
+
+// WithSchemaShardNamer sets the rule used to recognize sharded schema names.
+// A nil namer restores the default rule.
+func WithSchemaShardNamer(namer ShardNamer) Option {
+	return func(e *Extractor) { e.extractOpts = append(e.extractOpts, extract.WithSchemaShardNamer(namer)) }
+}
+
+// WithTableShardNamer sets the rule used to recognize sharded table names.
+// A nil namer restores the default rule.
+func WithTableShardNamer(namer ShardNamer) Option {
+	return func(e *Extractor) { e.extractOpts = append(e.extractOpts, extract.WithTableShardNamer(namer)) }
+}
+
+// WithInlineAggrLiterals sets whether literals inside aggregate functions are kept in the
+// templatized SQL (default) or parameterized like any other literal.
+func WithInlineAggrLiterals(inline bool) Option {
+	return func(e *Extractor) { e.extractOpts = append(e.extractOpts, extract.WithInlineAggrLiterals(inline)) }
+}
+
+// WithKeepLiterals sets whether literals at the given positions are kept in the templatized SQL
+// or parameterized, e.g. WithKeepLiterals(LiteralLimit|LiteralInterval, true) keeps `LIMIT 10`
+// and `INTERVAL 7 DAY` as is. Positions not given keep their current policy.
+func WithKeepLiterals(positions LiteralPosition, keep bool) Option {
+	return func(e *Extractor) { e.extractOpts = append(e.extractOpts, extract.WithKeepLiterals(positions, keep)) }
+}
+
+// WithLogger sets the logger used to report unhandled nodes, in addition to the warnings
+// returned by Warnings. Default discards the logs.
+func WithLogger(logger *slog.Logger) Option {
+	return func(e *Extractor) { e.extractOpts = append(e.extractOpts, extract.WithLogger(logger)) }
+}
+
+// WithStrict sets whether Extract returns an *UnhandledNodeError when some nodes
+// could not be fully templatized, instead of reporting them as warnings.
+func WithStrict(strict bool) Option {
+	return func(e *Extractor) { e.extractOpts = append(e.extractOpts, extract.WithStrict(strict)) }
+}
+
+// WithTiDBDigest sets whether the normalized SQL and digest of each statement are computed
+// as TiDB does, see TiDBNormalizedSQL and TiDBDigest.
+func WithTiDBDigest(enable bool) Option {
+	return func(e *Extractor) { e.extractOpts = append(e.extractOpts, extract.WithTiDBDigest(enable)) }
+}
+
+// WithSQLMode sets the SQL mode used to parse the SQL, e.g. mysql.ModeANSIQuotes.
+func WithSQLMode(mode mysql.SQLMode) Option {
+	return func(e *Extractor) { e.extractOpts = append(e.extractOpts, extract.WithSQLMode(mode)) }
+}
+
+// WithCharset sets the charset and collation used to parse the SQL.
+func WithCharset(charset, collation string) Option {
+	return func(e *Extractor) { e.extractOpts = append(e.extractOpts, extract.WithCharset(charset, collation)) }
+}
+
 // Extractor is a struct that holds the raw SQL, templatized SQL, operation type,
 // parameters and table information. It is used to extract information from a
 // SQL string.
@@ -19,11 +206,17 @@ type Extractor struct {
 	tableInfos   [][]*models.TableInfo // table infos: Schema, Tablename
 	hash         []string              // hash of the templatized SQL
 	hasPamMarker []bool                // whether the SQL contains parameter markers
+
+	statements []*models.Statement // per-statement results
+
+	hasher      func([]byte) string // hash function of the templatized SQL, sha256 if nil
+	extractOpts []extract.Option    // options passed to the underlying extractor
 }
 
-// NewExtractor creates a new Extractor. It requires a raw SQL string.
-func NewExtractor(sql string) *Extractor {
-	return &Extractor{
+// NewExtractor creates a new Extractor. It requires a raw SQL string,
+// and accepts options to configure the extraction behavior.
+func NewExtractor(sql string, opts ...Option) *Extractor {
+	e := &Extractor{
 		rawSQL:       sql,
 		templatedSQL: []string{},
 		opType:       []models.SQLOpType{},
@@ -31,7 +224,14 @@ func NewExtractor(sql string) *Extractor {
 		tableInfos:   [][]*models.TableInfo{},
 		hash:         []string{},
 		hasPamMarker: []bool{},
+		statements:   []*models.Statement{},
+	}
+
+	for _, opt := range opts {
+		opt(e)
 	}
+
+	return e
 }
 
 // RawSQL returns the raw SQL.
@@ -46,16 +246,59 @@ func (e *Extractor) TemplatizedSQL() []string { return e.templatedSQL }
 // Params returns the parameters.
 func (e *Extractor) Params() [][]any { return e.params }
 
+// ParamInfos returns the position, clause, bound column and original literal text of each
+// parameter, parallel to Params.
+func (e *Extractor) ParamInfos() [][]models.ParamInfo {
+	return lo.Map(e.statements, func(s *models.Statement, _ int) []models.ParamInfo { return s.ParamInfos })
+}
+
 // TableInfos returns the table infos.
 func (e *Extractor) TableInfos() [][]*models.TableInfo { return e.tableInfos }
 
+// Aliases returns the table aliases and CTE names of each statement, in the order they are declared.
+// Use Statement.ResolveAlias to resolve a column qualifier to its physical tables.
+func (e *Extractor) Aliases() [][]models.Alias {
+	return lo.Map(e.statements, func(s *models.Statement, _ int) []models.Alias { return s.Aliases })
+}
+
+// Columns returns the columns referenced by each statement, in the order they appear,
+// with the clause they appear in, whether they are read or written, and their owning tables.
+func (e *Extractor) Columns() [][]models.Column {
+	return lo.Map(e.statements, func(s *models.Statement, _ int) []models.Column { return s.Columns })
+}
+
+// Predicates returns the comparisons of columns against values of each statement, in the order
+// they appear. Use Statement.PredicateParams to read the compared values.
+func (e *Extractor) Predicates() [][]models.Predicate {
+	return lo.Map(e.statements, func(s *models.Statement, _ int) []models.Predicate { return s.Predicates })
+}
+
 // OpType returns the operation type.
 func (e *Extractor) OpType() []models.SQLOpType { return e.opType }
 
+// Statements returns the extraction result of each statement, in the order they
+// appear in the raw SQL.
+func (e *Extractor) Statements() []*models.Statement { return e.statements }
+
+// Warnings returns the nodes of all statements that could not be fully templatized.
+// The templatized SQL of the statements they belong to may be incomplete.
+func (e *Extractor) Warnings() []models.Warning {
+	var warnings []models.Warning
+	for _, stmt := range e.statements {
+		warnings = append(warnings, stmt.Warnings...)
+	}
+
+	return warnings
+}
+
 // doHash calculates the hash of the templatized SQL.
 func (e *Extractor) doHash(fn ...func([]byte) string) {
 	e.hash = make([]string, len(e.templatedSQL))
 
+	if len(fn) == 0 && e.hasher != nil {
+		fn = []func([]byte) string{e.hasher}
+	}
+
 	if len(fn) == 0 {
 		fn = []func([]byte) string{func(s []byte) string {
 			hash := sha256.Sum256(s)
@@ -66,16 +309,34 @@ func (e *Extractor) doHash(fn ...func([]byte) string) {
 	for i := range e.templatedSQL {
 		e.hash[i] = fn[0]([]byte(e.templatedSQL[i]))
 	}
+
+	for i := range e.statements {
+		e.statements[i].Hash = e.hash[i]
+	}
 }
 
 // TemplatizedSQLHash returns the hash of the templatized SQL.
+// The Hash of Statements is updated accordingly.
 //
-// Default hash function is sha256.
+// Default hash function is the one set by WithHasher, or sha256.
 func (e *Extractor) TemplatizedSQLHash(fn ...func([]byte) string) []string {
 	e.doHash(fn...)
 	return e.hash
 }
 
+// TiDBNormalizedSQL returns the normalized SQL of each statement as TiDB computes it,
+// i.e. the Normalized_sql of the slow log and DIGEST_TEXT of statements_summary,
+// e.g. "select `c` from `sbtest1` where `id` = ?". It requires WithTiDBDigest.
+func (e *Extractor) TiDBNormalizedSQL() []string {
+	return lo.Map(e.statements, func(s *models.Statement, _ int) string { return s.TiDBNormalizedSQL })
+}
+
+// TiDBDigest returns the digest of each statement as TiDB computes it, i.e. the Digest
+// of the slow log and DIGEST of statements_summary. It requires WithTiDBDigest.
+func (e *Extractor) TiDBDigest() []string {
+	return lo.Map(e.statements, func(s *models.Statement, _ int) string { return s.TiDBDigest })
+}
+
 // HasParamMarker returns whether the SQLs contains parameter markers.
 func (e *Extractor) HasParamMarker() []bool { return e.hasPamMarker }
 
@@ -91,11 +352,24 @@ func (e *Extractor) HasParamMarker() []bool { return e.hasPamMarker }
 //	}
 //	fmt.Println(extractor.TemplatizeSQL())
 func (e *Extractor) Extract() (err error) {
-	e.templatedSQL, e.tableInfos, e.params, e.opType, e.hasPamMarker, err = extract.
-		NewExtractor().Extract(e.rawSQL)
+	e.statements, err = extract.NewExtractor(e.extractOpts...).ExtractStatements(e.rawSQL)
 	if err != nil {
+		e.templatedSQL, e.params, e.tableInfos, e.opType, e.hasPamMarker, e.hash = nil, nil, nil, nil, nil, nil
 		return err
 	}
+
+	e.templatedSQL = make([]string, len(e.statements))
+	e.params = make([][]any, len(e.statements))
+	e.tableInfos = make([][]*models.TableInfo, len(e.statements))
+	e.opType = make([]models.SQLOpType, len(e.statements))
+	e.hasPamMarker = make([]bool, len(e.statements))
+	for i, stmt := range e.statements {
+		e.templatedSQL[i] = stmt.TemplatizedSQL
+		e.params[i] = stmt.Params
+		e.tableInfos[i] = stmt.TableInfos
+		e.opType[i] = stmt.OpType
+		e.hasPamMarker[i] = stmt.HasParamMarker
+	}
 	e.doHash()
 
 	return nil
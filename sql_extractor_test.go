@@ -1,14 +1,21 @@
 package sqlextractor
 
 import (
+	"bytes"
 	"crypto/md5"
 	"crypto/sha256"
 	"encoding/hex"
+	"log/slog"
+	"regexp"
+	"strings"
 	"testing"
 
+	"github.com/pingcap/tidb/pkg/parser/mysql"
+	"github.com/samber/lo"
 	"github.com/stretchr/testify/assert"
 
-	"github.com/kydenul/sql-extractor/internal/models"
+	"github.com/kydenul/sql-extractor/internal/testutil"
+	"github.com/kydenul/sql-extractor/models"
 )
 
 func TestExtractor_RawSQL(t *testing.T) {
@@ -273,7 +280,7 @@ func TestExtractor_0(t *testing.T) {
 	)
 	as.Equal([][]*models.TableInfo{
 		{
-			models.NewTableInfo("dbUserCart_3", "tbUserCart_67", "dbUserCart_?", "tbUserCart_?"),
+			testutil.WithShards(models.NewTableInfo("dbUserCart_3", "tbUserCart_67", "dbUserCart_?", "tbUserCart_?"), []string{"3"}, []string{"67"}),
 		},
 	}, extractor.TableInfos())
 	as.Equal(4, len(extractor.Params()[0]))
@@ -299,7 +306,7 @@ func TestExtractor_1(t *testing.T) {
 	as.Equal([]string{"SELECT sBizCode FROM dbUserRss.tbUserRss_? WHERE sUid eq ?"}, tsql)
 	as.Equal(
 		[][]*models.TableInfo{
-			{models.NewTableInfo("dbUserRss", "tbUserRss_1", "dbUserRss", "tbUserRss_?")},
+			{testutil.WithShards(models.NewTableInfo("dbUserRss", "tbUserRss_1", "dbUserRss", "tbUserRss_?"), nil, []string{"1"})},
 		},
 		extractor.TableInfos(),
 	)
@@ -319,13 +326,316 @@ func TestExtractor_1(t *testing.T) {
 		tsql)
 	as.Equal([][]*models.TableInfo{
 		{
-			models.NewTableInfo(
+			testutil.WithShards(testutil.WithAccess(models.NewTableInfo(
 				"db_check_in",
 				"check_in_daily_record_20250630",
 				"db_check_in",
 				"check_in_daily_record_?",
-			),
+			), models.AccessWrite), nil, []string{"20250630"}),
 		},
 	}, extractor.TableInfos())
 	as.Equal([]bool{true}, extractor.HasParamMarker())
 }
+
+func TestExtractor_RenderMode(t *testing.T) {
+	t.Parallel()
+	as := assert.New(t)
+
+	sql := "SELECT * FROM users WHERE name = 'kyden' AND age >= 18"
+	extractor := NewExtractor(sql)
+
+	err := extractor.Extract()
+	as.Nil(err)
+	as.Equal([]string{"SELECT * FROM users WHERE name eq ? and age ge ?"}, extractor.TemplatizedSQL())
+
+	extractor = NewExtractor(sql, WithRenderMode(RenderModeSQL))
+
+	err = extractor.Extract()
+	as.Nil(err)
+	as.Equal([]string{"SELECT * FROM users WHERE name = ? AND age >= ?"}, extractor.TemplatizedSQL())
+	as.Equal([][]any{{"kyden", int64(18)}}, extractor.Params())
+}
+
+func TestExtractor_Statements(t *testing.T) {
+	t.Parallel()
+	as := assert.New(t)
+
+	sql := "INSERT INTO users (name, age) VALUES ('Alice', 25);\n  UPDATE users SET age = 26 WHERE name = 'Alice' ;"
+	extractor := NewExtractor(sql)
+	as.Empty(extractor.Statements())
+
+	err := extractor.Extract()
+	as.Nil(err)
+
+	stmts := extractor.Statements()
+	as.Len(stmts, 2)
+	hash := extractor.TemplatizedSQLHash()
+	for i, stmt := range stmts {
+		as.Equal(i, stmt.Index)
+		as.Equal(sql[stmt.StartOffset:stmt.EndOffset], stmt.Text)
+		as.Equal(extractor.TemplatizedSQL()[i], stmt.TemplatizedSQL)
+		as.Equal(extractor.Params()[i], stmt.Params)
+		as.Equal(extractor.TableInfos()[i], stmt.TableInfos)
+		as.Equal(extractor.OpType()[i], stmt.OpType)
+		as.Equal(extractor.HasParamMarker()[i], stmt.HasParamMarker)
+		as.Equal(hash[i], stmt.Hash)
+	}
+
+	as.Equal("INSERT INTO users (name, age) VALUES ('Alice', 25)", stmts[0].Text)
+	as.Equal("UPDATE users SET age = 26 WHERE name = 'Alice'", stmts[1].Text)
+	as.Equal([]any{int64(26), "Alice"}, stmts[1].Params)
+	as.Equal(models.SQLOperationUpdate, stmts[1].OpType)
+
+	// custom hash function updates the statements as well
+	hash = extractor.TemplatizedSQLHash(func(b []byte) string {
+		sum := md5.Sum(b)
+		return hex.EncodeToString(sum[:])
+	})
+	as.Equal(hash[1], stmts[1].Hash)
+
+	// error resets the results
+	extractor.SetRawSQL("SELECT * FROM WHERE")
+	as.Error(extractor.Extract())
+	as.Empty(extractor.Statements())
+	as.Empty(extractor.TemplatizedSQL())
+}
+
+func TestExtractor_Options(t *testing.T) {
+	t.Parallel()
+	as := assert.New(t)
+
+	md5Hasher := func(b []byte) string {
+		sum := md5.Sum(b)
+		return hex.EncodeToString(sum[:])
+	}
+
+	sql := "SELECT * FROM db_1.orders_2024_10 WHERE id IN (1, 2, 3) AND name = 'kyden'"
+	extractor := NewExtractor(sql,
+		WithHasher(md5Hasher),
+		WithRenderMode(RenderModeSQL),
+		WithINListMode(INListExact),
+		WithShardPattern(regexp.MustCompile(`_(\d+(?:_\d+)*)$`)),
+	)
+
+	err := extractor.Extract()
+	as.Nil(err)
+	as.Equal([]string{"SELECT * FROM db_?.orders_? WHERE id IN (?, ?, ?) AND name = ?"}, extractor.TemplatizedSQL())
+	as.Equal([][]any{{int64(1), int64(2), int64(3), "kyden"}}, extractor.Params())
+	as.Equal(
+		[][]*models.TableInfo{{testutil.WithShards(models.NewTableInfo("db_1", "orders_2024_10", "db_?", "orders_?"), []string{"1"}, []string{"2024_10"})}},
+		extractor.TableInfos(),
+	)
+
+	hash := md5Hasher([]byte(extractor.TemplatizedSQL()[0]))
+	as.Equal([]string{hash}, extractor.TemplatizedSQLHash())
+	as.Equal(hash, extractor.Statements()[0].Hash)
+
+	// explicit hash function takes precedence
+	sha := sha256.Sum256([]byte(extractor.TemplatizedSQL()[0]))
+	as.Equal(
+		[]string{hex.EncodeToString(sha[:])},
+		extractor.TemplatizedSQLHash(func(b []byte) string {
+			sum := sha256.Sum256(b)
+			return hex.EncodeToString(sum[:])
+		}),
+	)
+
+	// logger, SQL mode, charset and aggregate literals
+	var buf bytes.Buffer
+	extractor = NewExtractor(`SELECT COUNT(1) FROM "users" WHERE a = 1; LOCK TABLES t READ`,
+		WithLogger(slog.New(slog.NewTextHandler(&buf, nil))),
+		WithSQLMode(mysql.ModeANSIQuotes),
+		WithCharset("utf8mb4", ""),
+		WithInlineAggrLiterals(false),
+	)
+	err = extractor.Extract()
+	as.Nil(err)
+	as.Equal("SELECT COUNT(?) FROM users WHERE a eq ?", extractor.TemplatizedSQL()[0])
+	as.Equal([]any{int64(1), int64(1)}, extractor.Params()[0])
+	as.Contains(buf.String(), "unhandled node type")
+
+	// JSON path
+	extractor = NewExtractor("SELECT doc->>'$.name' FROM users WHERE JSON_CONTAINS(doc, '1', '$.ids')",
+		WithJSONPathMode(JSONPathKeep),
+		WithRenderMode(RenderModeSQL),
+	)
+	err = extractor.Extract()
+	as.Nil(err)
+	as.Equal("SELECT JSON_UNQUOTE(JSON_EXTRACT(doc, '$.name')) FROM users WHERE JSON_CONTAINS(doc, ?, '$.ids')",
+		extractor.TemplatizedSQL()[0])
+	as.Equal([]any{"1"}, extractor.Params()[0])
+
+	// literal positions
+	extractor = NewExtractor("SELECT * FROM users WHERE ctime > NOW() - INTERVAL 7 DAY ORDER BY id LIMIT 100",
+		WithKeepLiterals(LiteralLimit|LiteralInterval, true),
+	)
+	err = extractor.Extract()
+	as.Nil(err)
+	as.Equal("SELECT * FROM users WHERE ctime gt DATE_SUB(NOW(), INTERVAL 7 DAY) ORDER BY id LIMIT 100",
+		extractor.TemplatizedSQL()[0])
+	as.Empty(extractor.Params()[0])
+
+	// shard namers
+	extractor = NewExtractor("SELECT * FROM db_0x1f.log_20241016 JOIN t_shard_a3",
+		WithSchemaShardNamer(HexSuffixNamer()),
+		WithTableShardNamer(ChainNamer(DateSuffixNamer(), RegexNamer(regexp.MustCompile(`_shard_([a-z]\d+)$`)))),
+	)
+	err = extractor.Extract()
+	as.Nil(err)
+	as.Equal("SELECT * FROM db_?.log_? CROSS JOIN t_shard_?", extractor.TemplatizedSQL()[0])
+	as.Equal([]*models.TableInfo{
+		testutil.WithShards(models.NewTableInfo("db_0x1f", "log_20241016", "db_?", "log_?"), []string{"0x1f"}, []string{"20241016"}),
+		testutil.WithShards(models.NewTableInfo("", "t_shard_a3", "", "t_shard_?"), nil, []string{"a3"}),
+	}, extractor.TableInfos()[0])
+}
+
+func TestExtractor_Warnings(t *testing.T) {
+	t.Parallel()
+	as := assert.New(t)
+
+	sql := "SELECT * FROM users WHERE id = 1; SELECT * FROM users WHERE id = NEXTVAL(seq)"
+	extractor := NewExtractor(sql)
+	err := extractor.Extract()
+	as.Nil(err)
+	as.Equal([]models.Warning{{
+		StmtIndex: 1,
+		NodeType:  "*ast.TableNameExpr",
+		Offset:    34,
+		Clause:    models.ClauseWhere,
+		Message:   "unhandled node type",
+	}}, extractor.Warnings())
+	as.Empty(extractor.Statements()[0].Warnings)
+
+	extractor = NewExtractor(sql, WithStrict(true))
+	err = extractor.Extract()
+	var unhandled *UnhandledNodeError
+	as.ErrorAs(err, &unhandled)
+	as.Equal(extractor.Warnings(), []models.Warning(nil))
+	as.Equal(34, unhandled.Warnings[0].Offset)
+
+	extractor.SetRawSQL("SELECT * FROM users WHERE id = 1")
+	as.Nil(extractor.Extract())
+	as.Empty(extractor.Warnings())
+}
+
+func TestExtractor_TiDBDigest(t *testing.T) {
+	t.Parallel()
+	as := assert.New(t)
+
+	extractor := NewExtractor("SELECT c FROM sbtest1 WHERE id=5021; SELECT c FROM sbtest1 WHERE id=1", WithTiDBDigest(true))
+	as.Nil(extractor.Extract())
+	as.Equal([]string{
+		"select `c` from `sbtest1` where `id` = ?",
+		"select `c` from `sbtest1` where `id` = ?",
+	}, extractor.TiDBNormalizedSQL())
+
+	digests := extractor.TiDBDigest()
+	as.Len(digests, 2)
+	as.Len(digests[0], 64)
+	as.Equal(digests[0], digests[1])
+	as.Equal(digests[0], extractor.Statements()[0].TiDBDigest)
+
+	// disabled by default
+	extractor = NewExtractor("SELECT c FROM sbtest1 WHERE id=5021")
+	as.Nil(extractor.Extract())
+	as.Equal([]string{""}, extractor.TiDBDigest())
+}
+
+func TestExtractor_Aliases(t *testing.T) {
+	t.Parallel()
+	as := assert.New(t)
+
+	extractor := NewExtractor("SELECT o.uid FROM orders AS o JOIN (SELECT id FROM users) AS d ON o.uid = d.id")
+	as.Nil(extractor.Extract())
+
+	aliases := extractor.Aliases()
+	as.Len(aliases, 1)
+	as.Equal([]string{"o", "d"}, lo.Map(aliases[0], func(a models.Alias, _ int) string { return a.Name }))
+	as.Equal(models.AliasTable, aliases[0][0].Kind)
+	as.Equal(models.AliasDerived, aliases[0][1].Kind)
+
+	stmt := extractor.Statements()[0]
+	as.Equal("orders", stmt.ResolveAlias("o")[0].TableName())
+	as.Equal("users", stmt.ResolveAlias("d")[0].TableName())
+}
+
+func TestExtractor_Access(t *testing.T) {
+	t.Parallel()
+	as := assert.New(t)
+
+	extractor := NewExtractor("INSERT INTO archive SELECT * FROM orders; SHOW COLUMNS FROM users")
+	as.Nil(extractor.Extract())
+
+	access := lo.Map(extractor.TableInfos(), func(tables []*models.TableInfo, _ int) []models.AccessMode {
+		return lo.Map(tables, func(ti *models.TableInfo, _ int) models.AccessMode { return ti.Access() })
+	})
+	as.Equal([][]models.AccessMode{{models.AccessWrite, models.AccessRead}, {models.AccessMetadata}}, access)
+}
+
+func TestExtractor_Columns(t *testing.T) {
+	t.Parallel()
+	as := assert.New(t)
+
+	extractor := NewExtractor("UPDATE users SET email = ? WHERE id = ?; SELECT phone FROM users")
+	as.Nil(extractor.Extract())
+
+	columns := extractor.Columns()
+	as.Len(columns, 2)
+	as.Equal([]string{"email", "id"}, lo.Map(columns[0], func(c models.Column, _ int) string { return c.Name }))
+	as.Equal(models.ClauseSet, columns[0][0].Clause)
+	as.Equal(models.AccessWrite, columns[0][0].Access)
+	as.Equal(models.ClauseWhere, columns[0][1].Clause)
+	as.Equal(models.AccessRead, columns[0][1].Access)
+	as.Equal("users", columns[0][0].Tables[0].TableName())
+
+	as.Equal("phone", columns[1][0].Name)
+	as.Equal(models.ClauseSelect, columns[1][0].Clause)
+}
+
+func TestExtractor_Predicates(t *testing.T) {
+	t.Parallel()
+	as := assert.New(t)
+
+	extractor := NewExtractor("SELECT * FROM orders WHERE uid = 42 AND status IN (1, 2)")
+	as.Nil(extractor.Extract())
+
+	predicates := extractor.Predicates()
+	as.Len(predicates, 1)
+	as.Len(predicates[0], 2)
+
+	uid := predicates[0][0]
+	as.Equal("uid", uid.Column.Name)
+	as.Equal(models.PredicateEQ, uid.Operator)
+	as.Equal([]models.LogicOp{models.LogicAnd}, uid.Context)
+	as.Equal([]any{int64(42)}, extractor.Statements()[0].PredicateParams(&uid))
+
+	status := predicates[0][1]
+	as.Equal(models.PredicateIn, status.Operator)
+	as.Equal([]int{1, 2}, status.ParamIndexes)
+}
+
+func TestExtractor_ParamInfos(t *testing.T) {
+	t.Parallel()
+	as := assert.New(t)
+
+	sql := "SELECT * FROM orders WHERE uid = 0x1F AND created > '2024-01-01' LIMIT 10"
+	extractor := NewExtractor(sql)
+	as.Nil(extractor.Extract())
+
+	infos := extractor.ParamInfos()
+	as.Len(infos, 1)
+	as.Len(infos[0], len(extractor.Params()[0]))
+
+	as.Equal("0x1F", infos[0][0].Text)
+	as.Equal(strings.Index(sql, "0x1F"), infos[0][0].Offset)
+	as.Equal(models.ClauseWhere, infos[0][0].Clause)
+	as.Equal("uid", infos[0][0].Column.Name)
+
+	as.Equal("'2024-01-01'", infos[0][1].Text)
+	as.Equal("created", infos[0][1].Column.Name)
+
+	as.Equal("10", infos[0][2].Text)
+	as.Equal(2, infos[0][2].Placeholder)
+	as.Equal(models.ClauseLimit, infos[0][2].Clause)
+	as.Nil(infos[0][2].Column)
+}